@@ -0,0 +1,190 @@
+package fetcher
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AlgoliaSourceType    = "algolia"
+	AlgoliaBaseUrl       = "https://hn.algolia.com/api/v1"
+	AlgoliaDefaultTags   = "story"
+	AlgoliaHitsPerPage   = 100
+	AlgoliaDefaultWindow = 24
+)
+
+// Regex syntax that does not change what a plain-word pattern matches
+var plainPatternNoise = strings.NewReplacer(`\b`, "", "^", "", "$", "", "(?i)", "", `\s`, " ", `\.`, ".")
+
+// What is left must be words only to be usable as a search query
+var plainQuery = regexp.MustCompile(`^[\w .'-]+$`)
+
+type algoliaResponse struct {
+	Hits []struct {
+		ObjectId    string `json:"objectID"`
+		Title       string `json:"title"`
+		Url         string `json:"url"`
+		Author      string `json:"author"`
+		StoryText   string `json:"story_text"`
+		CreatedAt   int64  `json:"created_at_i"`
+		Points      int64  `json:"points"`
+		NumComments int64  `json:"num_comments"`
+	} `json:"hits"`
+}
+
+// AlgoliaSource HackerNews stories found by the HN Search API with the configured filters
+// as keyword queries. It picks up the stories that rose and fell between the runs. The items
+// keep their HackerNews IDs, so they are deduplicated together with the HackerNews source.
+type AlgoliaSource struct {
+	name           string
+	baseUrl        string
+	queries        []string
+	tags           string
+	numericFilters string
+	hoursBack      uint
+	items          map[int64]JsonNewsItem
+	hackerNews     *HackerNewsSource
+	client         *HttpClient
+}
+
+func newAlgoliaSource(settings *Configuration, config *SourceConfig, client *HttpClient) (Source, error) {
+	source := &AlgoliaSource{
+		name:           config.Name,
+		baseUrl:        config.Url,
+		queries:        config.Queries,
+		tags:           config.Tags,
+		numericFilters: config.NumericFilters,
+		hoursBack:      config.HoursBack,
+		client:         client,
+		// Pending items that are not in the search results anymore are fetched from the HackerNews API
+		hackerNews: &HackerNewsSource{name: config.Name, baseUrl: settings.ApiBaseUrl, client: client},
+	}
+
+	if source.baseUrl == "" {
+		source.baseUrl = AlgoliaBaseUrl
+	}
+
+	if source.tags == "" {
+		source.tags = AlgoliaDefaultTags
+	}
+
+	if source.hoursBack == 0 {
+		source.hoursBack = AlgoliaDefaultWindow
+	}
+
+	if len(source.queries) == 0 {
+		source.queries = filterQueries(settings.Filters)
+	}
+
+	if len(source.queries) == 0 {
+		return nil, fmt.Errorf("no queries are set, and none of the filters is a plain word")
+	}
+
+	return source, nil
+}
+
+// Turn the filter patterns that are plain words into search queries. Patterns
+// using other regex syntax can't be expressed as a query and are left out.
+func filterQueries(filters []FilterItem) []string {
+	var queries []string
+
+	seen := make(map[string]bool)
+
+	for _, filter := range filters {
+		for _, pattern := range strings.Split(filter.Value, ",") {
+			query := strings.ToLower(strings.TrimSpace(plainPatternNoise.Replace(pattern)))
+
+			if query == "" || seen[query] || !plainQuery.MatchString(query) {
+				continue
+			}
+
+			seen[query] = true
+			queries = append(queries, query)
+		}
+	}
+
+	return queries
+}
+
+func (s *AlgoliaSource) Name() string {
+	return s.name
+}
+
+func (s *AlgoliaSource) searchURL(query string) string {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("tags", s.tags)
+	params.Set("hitsPerPage", strconv.Itoa(AlgoliaHitsPerPage))
+
+	numericFilters := fmt.Sprintf("created_at_i>%d", time.Now().Add(-time.Duration(s.hoursBack)*time.Hour).Unix())
+	if s.numericFilters != "" {
+		numericFilters += "," + s.numericFilters
+	}
+
+	params.Set("numericFilters", numericFilters)
+
+	return fmt.Sprintf("%s/search_by_date?%s", s.baseUrl, params.Encode())
+}
+
+// Prefetch Run every query and return the found stories' IDs, deduplicated
+func (s *AlgoliaSource) Prefetch() (*[]int64, error) {
+	var result []int64
+
+	s.items = make(map[int64]JsonNewsItem)
+
+	for _, query := range s.queries {
+		var response algoliaResponse
+
+		if err := s.client.GetJSON(s.searchURL(query), &response); err != nil {
+			return &result, err
+		}
+
+		for _, hit := range response.Hits {
+			id, err := strconv.ParseInt(hit.ObjectId, 10, 64)
+			if err != nil {
+				continue
+			}
+
+			if _, seen := s.items[id]; seen {
+				continue
+			}
+
+			item := JsonNewsItem{
+				Id:          id,
+				Title:       hit.Title,
+				Url:         hit.Url,
+				By:          hit.Author,
+				Text:        hit.StoryText,
+				Type:        "story",
+				Time:        hit.CreatedAt,
+				Score:       hit.Points,
+				Descendants: hit.NumComments,
+				Source:      s.name,
+				Discussion:  fmt.Sprintf(HackerNewsItemUrl, id),
+			}
+
+			// Ask HN, Show HN and other text posts link to their discussion
+			if item.Url == "" {
+				item.Url = item.Discussion
+			}
+
+			s.items[id] = item
+			result = append(result, id)
+		}
+	}
+
+	return &result, nil
+}
+
+// FetchOne Return a found story, or fetch it from the HackerNews API if the last search did not find it
+func (s *AlgoliaSource) FetchOne(id int64) (JsonNewsItem, error) {
+	if item, ok := s.items[id]; ok {
+		return item, nil
+	}
+
+	return s.hackerNews.FetchOne(id)
+}
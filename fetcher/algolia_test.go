@@ -0,0 +1,106 @@
+package fetcher
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestFilterQueries(t *testing.T) {
+	queries := filterQueries([]FilterItem{
+		{Title: "SQL", Value: "sql"},
+		{Title: "JavaScript", Value: `\bjs\b,(ecma|java).*script,\bnpm\b`},
+		{Title: "Linux", Value: `\blinux\b,\bopen[\s-]source\b,SQL`},
+	})
+	expected := []string{"sql", "js", "npm", "linux"}
+
+	if len(queries) != len(expected) {
+		t.Fatalf("Expected queries %v, got %v", expected, queries)
+	}
+
+	for idx, query := range expected {
+		if queries[idx] != query {
+			t.Errorf("Expected queries %v, got %v", expected, queries)
+		}
+	}
+}
+
+func TestAlgoliaSource(t *testing.T) {
+	const (
+		linuxHits = `{"hits": [
+			{"objectID": "33214440", "title": "Some Linux Title", "url": "https://some-host/1-story-uri/",
+			 "author": "author", "points": 10, "num_comments": 1, "created_at_i": 1665839487},
+			{"objectID": "33215770", "title": "Some Linux News", "url": "https://some-host/2-story-uri/",
+			 "author": "author-2", "points": 20, "num_comments": 3, "created_at_i": 1665839234}
+		]}`
+		oldNewsItemID = int64(33214440)
+	)
+
+	fetcher := Fetcher{Settings: Configuration{
+		Filters:  []FilterItem{{Title: "Linux", Value: `\blinux\b`}},
+		Sources:  []SourceConfig{{Type: AlgoliaSourceType}},
+		Database: Database{Driver: "sqlite3", Database: ":memory:"},
+	}}
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
+
+	sources, err := newSources(&fetcher.Settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fetcher.setUpRepository(); err != nil {
+		t.Fatalf("Error while initializing the repository, %v", err)
+	}
+
+	defer fetcher.repository.Close()
+
+	// An item already seen through the HackerNews source
+	if err := fetcher.repository.UpdateItems(&[]DigestItem{{id: oldNewsItemID, newsTitle: "Existing Title",
+		newsUrl: "http://host"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	httpmock.Activate()
+
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterRegexpResponder("GET",
+		regexp.MustCompile(`^`+regexp.QuoteMeta(AlgoliaBaseUrl)+`/search_by_date\?.*query=linux`),
+		httpmock.NewStringResponder(200, linuxHits))
+
+	prefetched, err := sources[0].Prefetch()
+	if err != nil {
+		t.Fatalf("Error while searching, %v", err)
+	}
+
+	if len(*prefetched) != 2 {
+		t.Fatalf("Expected 2 found stories, got %d", len(*prefetched))
+	}
+
+	unfiltered, filtered, err := fetcher.filter(sources[0], prefetched)
+	if err != nil {
+		t.Fatalf("Error while filtering news items, %v", err)
+	}
+
+	if len(*unfiltered) != 1 || len(*filtered) != 1 || (*filtered)[0].id != 33215770 {
+		t.Fatalf("Expected only the story not seen before, got %v", *filtered)
+	}
+
+	if (*filtered)[0].score != 20 || (*filtered)[0].author != "author-2" {
+		t.Errorf("Unexpected story details %v", (*filtered)[0])
+	}
+}
+
+func TestAlgoliaSourceWithoutQueries(t *testing.T) {
+	settings := Configuration{
+		Filters: []FilterItem{{Title: "JavaScript", Value: "(ecma|java).*script"}},
+		Sources: []SourceConfig{{Type: AlgoliaSourceType}},
+	}
+
+	if _, err := newSources(&settings); err == nil {
+		t.Error("Expected an Algolia source without usable queries to fail")
+	}
+}
@@ -7,9 +7,16 @@ import (
 )
 
 type ArgParser struct {
-	Config  string
-	Reverse bool
-	Vacuum  bool
+	Config     string
+	Backtest   string
+	Since      string
+	TestFile   string
+	TestFilter []string
+	Relevant   []string
+	Irrelevant []string
+	Reverse    bool
+	Vacuum     bool
+	Refilter   bool
 }
 
 func (p *ArgParser) Parse() error {
@@ -19,6 +26,20 @@ func (p *ArgParser) Parse() error {
 	vacuum := parser.Flag("v", "vacuum", &argparse.Options{Required: false, Help: "Remove old records"})
 	config := parser.String("c", "config", &argparse.Options{Required: false,
 		Help: "Configuration file", Default: "./config.json"})
+	testFilter := parser.StringList("t", "test-filter", &argparse.Options{Required: false,
+		Help: "Explain which filters hit a title, a URL, or a title followed by a URL"})
+	testFile := parser.String("", "test-file", &argparse.Options{Required: false,
+		Help: "Explain which filters hit the titles and URLs in a file, one per line"})
+	backtest := parser.String("b", "backtest", &argparse.Options{Required: false,
+		Help: "Replay the stored news items through the filters of another configuration file"})
+	refilter := parser.Flag("", "refilter", &argparse.Options{Required: false,
+		Help: "Run the stored news items through the filters and deliver the matches never delivered"})
+	since := parser.String("s", "since", &argparse.Options{Required: false,
+		Help: "Period of the stored news items to re-filter, e.g. 7d or 12h", Default: DefaultRefilterSince})
+	relevant := parser.StringList("", "train-relevant", &argparse.Options{Required: false,
+		Help: "Train the classifier with the stored item linking to an article or discussion URL as relevant"})
+	irrelevant := parser.StringList("", "train-irrelevant", &argparse.Options{Required: false,
+		Help: "Train the classifier with the stored item linking to an article or discussion URL as irrelevant"})
 
 	err := parser.Parse(os.Args)
 	if err != nil {
@@ -28,6 +49,13 @@ func (p *ArgParser) Parse() error {
 	p.Reverse = *reverse
 	p.Vacuum = *vacuum
 	p.Config = *config
+	p.TestFilter = *testFilter
+	p.TestFile = *testFile
+	p.Backtest = *backtest
+	p.Refilter = *refilter
+	p.Since = *since
+	p.Relevant = *relevant
+	p.Irrelevant = *irrelevant
 
 	return nil
 }
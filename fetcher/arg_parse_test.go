@@ -27,13 +27,26 @@ func TestArgParseEmptySet(t *testing.T) {
 	if args.Vacuum {
 		t.Fatal("--vacuum was not set, should be false")
 	}
+
+	if len(args.TestFilter) != 0 || args.TestFile != "" {
+		t.Fatal("--test-filter and --test-file were not set, should be empty")
+	}
+
+	if args.Backtest != "" {
+		t.Fatal("--backtest was not set, should be empty")
+	}
+
+	if args.Refilter || args.Since != DefaultRefilterSince {
+		t.Fatal("--refilter and --since were not set, should be the defaults")
+	}
 	// Restore the old Args
 	os.Args = prevArgs
 }
 
 func TestArgParseValueSet(t *testing.T) {
 	prevArgs := os.Args
-	newArgs := []string{"self", "-r", "-v", "-c", "another-config.json"}
+	newArgs := []string{"self", "-r", "-v", "-c", "another-config.json", "-b", "candidate.json",
+		"--refilter", "-s", "3d", "--train-relevant", "http://host/1", "--train-irrelevant", "http://host/2"}
 
 	os.Args = newArgs
 
@@ -54,6 +67,40 @@ func TestArgParseValueSet(t *testing.T) {
 	if !args.Vacuum {
 		t.Fatal("--vacuum was set, should be true")
 	}
+
+	if args.Backtest != "candidate.json" {
+		t.Fatal("--backtest was set, should be the candidate config")
+	}
+
+	if !args.Refilter || args.Since != "3d" {
+		t.Fatal("--refilter and --since were set, should be true and 3d")
+	}
+
+	if len(args.Relevant) != 1 || args.Relevant[0] != "http://host/1" ||
+		len(args.Irrelevant) != 1 || args.Irrelevant[0] != "http://host/2" {
+		t.Fatal("--train-relevant and --train-irrelevant were set, should be the links")
+	}
+	// Restore the old Args
+	os.Args = prevArgs
+}
+
+func TestArgParseTestFilter(t *testing.T) {
+	prevArgs := os.Args
+	os.Args = []string{"self", "-t", "Some title", "--test-filter", "https://host/", "--test-file", "titles.txt"}
+
+	args := ArgParser{}
+
+	if err := args.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(args.TestFilter) != 2 || args.TestFilter[0] != "Some title" || args.TestFilter[1] != "https://host/" {
+		t.Fatalf("--test-filter was set twice, got %v", args.TestFilter)
+	}
+
+	if args.TestFile != "titles.txt" {
+		t.Fatalf("--test-file was set, got %s", args.TestFile)
+	}
 	// Restore the old Args
 	os.Args = prevArgs
 }
@@ -0,0 +1,163 @@
+package fetcher
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// Date format of the daily volumes
+const BacktestDayFormat = "2006-01-02"
+
+// How the digest of the stored news items changes with another filter configuration
+type backtestReport struct {
+	items int
+	// Titles of the filters of both configurations, the current ones first
+	filters                []string
+	currentHits, newHits   map[string]int
+	currentTotal, newTotal int
+	included, dropped      []JsonNewsItem
+	days                   []string
+	currentDaily, newDaily map[string]int
+}
+
+// Whether a stored news item would get into the digest if it were fetched now
+func (f *Fetcher) wouldInclude(storedItem JsonNewsItem) bool {
+	newItem := fetchedNow(storedItem)
+
+	return f.evaluate(&newItem) == verdictInclude
+}
+
+// Run the stored news items through the filters of the current and the candidate configurations
+func (f *Fetcher) backtest(candidate *Fetcher, items []JsonNewsItem) backtestReport {
+	report := backtestReport{
+		items:        len(items),
+		currentHits:  map[string]int{},
+		newHits:      map[string]int{},
+		currentDaily: map[string]int{},
+		newDaily:     map[string]int{},
+	}
+
+	for _, settings := range [][]FilterItem{configuredFilters(&f.Settings), configuredFilters(&candidate.Settings)} {
+		for _, filter := range settings {
+			if !slices.Contains(report.filters, filter.Title) {
+				report.filters = append(report.filters, filter.Title)
+			}
+		}
+	}
+
+	for idx := range items {
+		for _, filter := range f.matchingFilters(&items[idx]) {
+			report.currentHits[filter.Title]++
+		}
+
+		for _, filter := range candidate.matchingFilters(&items[idx]) {
+			report.newHits[filter.Title]++
+		}
+
+		day := time.Unix(items[idx].Time, 0).Format(BacktestDayFormat)
+		if !slices.Contains(report.days, day) {
+			report.days = append(report.days, day)
+		}
+
+		current, next := f.wouldInclude(items[idx]), candidate.wouldInclude(items[idx])
+
+		if current {
+			report.currentTotal++
+			report.currentDaily[day]++
+		}
+
+		if next {
+			report.newTotal++
+			report.newDaily[day]++
+		}
+
+		switch {
+		case next && !current:
+			report.included = append(report.included, items[idx])
+		case current && !next:
+			report.dropped = append(report.dropped, items[idx])
+		}
+	}
+
+	return report
+}
+
+// Print the report as tables of the filter hits and the daily volumes, and lists of the changes
+func (report *backtestReport) print(out io.Writer) {
+	fmt.Fprintf(out, "Stored items: %d\n\n", report.items)
+
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(table, "Filter\tCurrent\tNew")
+	for _, title := range report.filters {
+		fmt.Fprintf(table, "%s\t%d\t%d\n", title, report.currentHits[title], report.newHits[title])
+	}
+
+	fmt.Fprintf(table, "Digest items\t%d\t%d\n", report.currentTotal, report.newTotal)
+	table.Flush()
+
+	for _, changes := range []struct {
+		title string
+		items []JsonNewsItem
+	}{{"Newly included", report.included}, {"Dropped", report.dropped}} {
+		fmt.Fprintf(out, "\n%s: %d\n", changes.title, len(changes.items))
+
+		for _, item := range changes.items {
+			fmt.Fprintf(out, "* %s - %s (%s)\n", item.Title, item.Url,
+				time.Unix(item.Time, 0).Format(BacktestDayFormat))
+		}
+	}
+
+	fmt.Fprintln(out)
+
+	fmt.Fprintln(table, "Day\tCurrent\tNew")
+	for _, day := range report.days {
+		fmt.Fprintf(table, "%s\t%d\t%d\n", day, report.currentDaily[day], report.newDaily[day])
+	}
+
+	table.Flush()
+}
+
+// Backtest Replay the stored news items through the filters of the current and the candidate
+// configurations, and report the filter hits, the items newly included or dropped by the
+// candidate, and the daily digest volumes. The stored items are read from the current database.
+func (f *Fetcher) Backtest(candidate Configuration, out io.Writer) error {
+	next := Fetcher{Settings: candidate, Reverse: f.Reverse}
+
+	if err := f.prepareFilters(); err != nil {
+		return err
+	}
+
+	if err := next.prepareFilters(); err != nil {
+		return err
+	}
+
+	if err := f.setUpRepository(); err != nil {
+		return err
+	}
+
+	defer f.repository.Close()
+
+	// Both configurations use the classifier's model of the current database
+	if err := f.loadClassifier(&f.repository); err != nil {
+		return err
+	}
+
+	if err := next.loadClassifier(&f.repository); err != nil {
+		return err
+	}
+
+	items, err := f.repository.StoredItems()
+	if err != nil {
+		return err
+	}
+
+	report := f.backtest(&next, items)
+	report.print(out)
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package fetcher
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBacktest(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		Filters:  []FilterItem{{Title: "Linux", Value: "linux"}, {Title: "Go", Value: "\\bgo\\b"}},
+		Database: Database{Driver: "sqlite3", Database: ":memory:"},
+	}}
+
+	candidate := Configuration{
+		Filters: []FilterItem{{Title: "Linux", Value: "linux", MinScore: 50}, {Title: "Rust", Value: "rust"}},
+	}
+
+	if err := fetcher.setUpRepository(); err != nil {
+		t.Fatalf("Error while initializing the repository, %v", err)
+	}
+
+	today := time.Now()
+	yesterday := today.AddDate(0, 0, -1)
+
+	err := fetcher.repository.UpdateItems(&[]DigestItem{
+		{id: 1, newsTitle: "Linux 7.0 released", newsUrl: "http://host/1", createdAt: yesterday.Unix(), score: 100},
+		{id: 2, newsTitle: "Linux on a toaster", newsUrl: "http://host/2", createdAt: yesterday.Unix(), score: 10},
+		{id: 3, newsTitle: "Go 2 is out", newsUrl: "http://host/3", createdAt: today.Unix()},
+		{id: 4, newsTitle: "Rust in the kernel", newsUrl: "http://host/4", createdAt: today.Unix()},
+	})
+	if err != nil {
+		t.Fatalf("Could not store the items, %v", err)
+	}
+
+	items, err := fetcher.repository.StoredItems()
+	if err != nil {
+		t.Fatalf("Could not load the stored items, %v", err)
+	}
+
+	next := Fetcher{Settings: candidate}
+
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
+
+	if err := next.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the candidate filters, %v", err)
+	}
+
+	report := fetcher.backtest(&next, items)
+	fetcher.repository.Close()
+
+	if strings.Join(report.filters, ",") != "Linux,Go,Rust" {
+		t.Errorf("Expected the filters of both configurations, got %v", report.filters)
+	}
+
+	if report.currentHits["Linux"] != 2 || report.newHits["Linux"] != 2 || report.newHits["Go"] != 0 ||
+		report.newHits["Rust"] != 1 {
+		t.Errorf("Unexpected filter hits, %v and %v", report.currentHits, report.newHits)
+	}
+
+	if report.currentTotal != 3 || report.newTotal != 2 {
+		t.Errorf("Expected 3 items in the current digest and 2 in the new one, got %d and %d",
+			report.currentTotal, report.newTotal)
+	}
+
+	if len(report.included) != 1 || report.included[0].Id != 4 {
+		t.Errorf("Expected the Rust item to be newly included, got %v", report.included)
+	}
+
+	if len(report.dropped) != 2 || report.dropped[0].Id != 2 || report.dropped[1].Id != 3 {
+		t.Errorf("Expected the low-score Linux and the Go items to be dropped, got %v", report.dropped)
+	}
+
+	day := today.Format(BacktestDayFormat)
+	if len(report.days) != 2 || report.currentDaily[day] != 1 || report.newDaily[day] != 1 {
+		t.Errorf("Unexpected daily volumes, %v and %v", report.currentDaily, report.newDaily)
+	}
+
+	var out strings.Builder
+	report.print(&out)
+
+	for _, expected := range []string{"Stored items: 4", "Rust          0        1", "Newly included: 1",
+		"* Rust in the kernel - http://host/4", "Dropped: 2", day + "  1        1"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected %q in the report, got\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestBacktestInvalidCandidate(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{Database: Database{Driver: "sqlite3", Database: ":memory:"}}}
+	candidate := Configuration{Filters: []FilterItem{{Title: "Broken", Value: "(unclosed"}}}
+
+	var out strings.Builder
+
+	if err := fetcher.Backtest(candidate, &out); err == nil {
+		t.Errorf("Expected the invalid candidate filter to be reported")
+	}
+}
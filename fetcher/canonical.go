@@ -0,0 +1,60 @@
+package fetcher
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// Days a delivered link is not delivered again under another item, unless configured in "Dedup"
+const DefaultRepostWindowDays = 7
+
+// Query parameters that only track where a visitor came from; the "utm_" ones are all dropped
+var TrackingParameters = []string{
+	"fbclid", "gclid", "dclid", "msclkid", "yclid", "igshid", "mc_cid", "mc_eid", "_hsenc", "_hsmi",
+	"ref", "ref_src", "ref_url", "si", "spm",
+}
+
+// Subdomains of the mobile and the "www" versions of the sites
+var canonicalHostPrefixes = []string{"www.", "m.", "mobile."}
+
+// Canonical form of a link, to tell the reposts of an article: the scheme, the fragment, the
+// tracking parameters, the trailing slash and the "www." or "m." subdomain are dropped, the host
+// is lower-cased and the query is sorted, e.g. "http://m.Example.com/a/?utm_source=x&b=2&a=1"
+// is "example.com/a?a=1&b=2". Links that do not parse are kept as they are.
+func canonicalURL(rawURL string) string {
+	parsedURL, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || parsedURL.Host == "" {
+		return rawURL
+	}
+
+	host := urlHost(rawURL)
+	for _, prefix := range canonicalHostPrefixes {
+		if rest, found := strings.CutPrefix(host, prefix); found && strings.Contains(rest, ".") {
+			host = rest
+			break
+		}
+	}
+
+	if port := parsedURL.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	query := parsedURL.Query()
+	for key := range query {
+		lowerKey := strings.ToLower(key)
+		if strings.HasPrefix(lowerKey, "utm_") || slices.Contains(TrackingParameters, lowerKey) {
+			query.Del(key)
+		}
+	}
+
+	canonical := host + strings.TrimRight(parsedURL.EscapedPath(), "/")
+
+	// Encode sorts the parameters by their names
+	if len(query) > 0 {
+		canonical += "?" + query.Encode()
+	}
+
+	return canonical
+}
@@ -0,0 +1,22 @@
+package fetcher
+
+import "testing"
+
+func TestCanonicalURL(t *testing.T) {
+	for rawURL, expected := range map[string]string{
+		"https://example.com/article":                                "example.com/article",
+		"http://www.Example.COM/article/":                            "example.com/article",
+		"https://m.example.com/article?utm_source=hn&utm_medium=rss": "example.com/article",
+		"https://example.com:443/article#comments":                   "example.com/article",
+		"https://example.com:8080/article":                           "example.com:8080/article",
+		"https://example.com/search?q=go&fbclid=abc&a=1":             "example.com/search?a=1&q=go",
+		"https://example.com/":                                       "example.com",
+		"https://m.com/article":                                      "m.com/article",
+		"https://example.com/Case/Sensitive":                         "example.com/Case/Sensitive",
+		"":                                                           "",
+	} {
+		if canonical := canonicalURL(rawURL); canonical != expected {
+			t.Errorf("Expected %q to be %q, got %q", rawURL, expected, canonical)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package fetcher
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Labels of the items the classifier is trained with
+const (
+	LabelRelevant   = "relevant"
+	LabelIrrelevant = "irrelevant"
+)
+
+// Classifier defaults, used unless configured in "Classifier"
+const (
+	DefaultClassifierTitle       = "Classifier"
+	DefaultClassifierThreshold   = 0.8
+	DefaultClassifierMinExamples = 10
+)
+
+// The number of the items trained with a label is kept as the count of the empty feature,
+// which no item has, as the features are prefixed with their fields
+const ClassifierItemsFeature = ""
+
+var ClassifierLabels = []string{LabelRelevant, LabelIrrelevant}
+
+var titleToken = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// Naive-Bayes model of the relevant and irrelevant items, built from the trained feature counts
+type classifierModel struct {
+	// Number of the items trained with each label
+	items map[string]int64
+	// Number of the items of each label that have each feature
+	counts map[string]map[string]int64
+	// Sum of the feature counts of each label
+	totals map[string]int64
+	// All the features seen in training
+	vocabulary map[string]bool
+}
+
+func newClassifierModel() *classifierModel {
+	model := &classifierModel{
+		items:      map[string]int64{},
+		counts:     map[string]map[string]int64{},
+		totals:     map[string]int64{},
+		vocabulary: map[string]bool{},
+	}
+
+	for _, label := range ClassifierLabels {
+		model.counts[label] = map[string]int64{}
+	}
+
+	return model
+}
+
+// Add the count of items of a label that have a feature
+func (model *classifierModel) add(feature, label string, items int64) {
+	if feature == ClassifierItemsFeature {
+		model.items[label] += items
+		return
+	}
+
+	if _, known := model.counts[label]; !known {
+		return
+	}
+
+	model.counts[label][feature] += items
+	model.totals[label] += items
+	model.vocabulary[feature] = true
+}
+
+// Whether every label has been trained with enough items for the model to be used
+func (model *classifierModel) ready(minExamples int64) bool {
+	for _, label := range ClassifierLabels {
+		if model.items[label] < minExamples {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Probability of a news item being relevant. Every feature is counted once per item, and the
+// counts are smoothed, so that the features never seen with a label do not rule it out.
+func (model *classifierModel) probability(newItem *JsonNewsItem) float64 {
+	var trained int64
+
+	for _, label := range ClassifierLabels {
+		trained += model.items[label]
+	}
+
+	features := itemFeatures(newItem)
+	scores := map[string]float64{}
+
+	for _, label := range ClassifierLabels {
+		score := math.Log(float64(model.items[label]+1) / float64(trained+2))
+		total := float64(max(model.totals[label]+int64(len(model.vocabulary)), 1))
+
+		for _, feature := range features {
+			score += math.Log(float64(model.counts[label][feature]+1) / total)
+		}
+
+		scores[label] = score
+	}
+
+	return 1 / (1 + math.Exp(scores[LabelIrrelevant]-scores[LabelRelevant]))
+}
+
+// Features of a news item the classifier learns from: the words of its title, its domain and
+// its author, prefixed with their fields, e.g. "title:rust", "domain:github.com" and "by:pg"
+func itemFeatures(newItem *JsonNewsItem) []string {
+	var features []string
+
+	seen := map[string]bool{}
+	add := func(field, value string) {
+		feature := field + ":" + value
+		if value != "" && !seen[feature] {
+			seen[feature] = true
+			features = append(features, feature)
+		}
+	}
+
+	for _, token := range titleToken.FindAllString(strings.ToLower(newItem.Title), -1) {
+		// Single letters and digits say little about an item
+		if utf8.RuneCountInString(token) > 1 {
+			add("title", token)
+		}
+	}
+
+	add("domain", itemDomain(newItem))
+	add("by", strings.ToLower(newItem.By))
+
+	return features
+}
+
+// Filter the classifier's matches are reported and grouped as
+func classifierFilter(settings *ClassifierConfig) FilterItem {
+	title := settings.Title
+	if title == "" {
+		title = DefaultClassifierTitle
+	}
+
+	return FilterItem{Title: title, Weight: settings.Weight}
+}
+
+// Filters of a configuration, with the classifier last, if it is enabled
+func configuredFilters(settings *Configuration) []FilterItem {
+	if !settings.Classifier.Enabled {
+		return settings.Filters
+	}
+
+	return append(append([]FilterItem{}, settings.Filters...), classifierFilter(&settings.Classifier))
+}
+
+// Check the classifier's settings
+func validateClassifier(settings *ClassifierConfig) error {
+	if threshold := optional(settings.Threshold, DefaultClassifierThreshold); threshold < 0 || threshold > 1 {
+		return fmt.Errorf("classifier threshold %v is not between 0 and 1", threshold)
+	}
+
+	return nil
+}
+
+// Load the classifier's model from the repository, if the classifier is enabled. It is not used
+// until it has been trained with enough relevant and irrelevant items.
+func (f *Fetcher) loadClassifier(repo *DataRepository) error {
+	f.classifier = nil
+
+	if !f.Settings.Classifier.Enabled {
+		return nil
+	}
+
+	model, err := repo.LoadClassifier()
+	if err != nil {
+		return err
+	}
+
+	minExamples := int64(optional(f.Settings.Classifier.MinExamples, DefaultClassifierMinExamples))
+
+	if !model.ready(minExamples) {
+		log.Println("CLASSIFIER: ", fmt.Sprintf("not used until trained with %d relevant and %d irrelevant items",
+			minExamples, minExamples))
+
+		return nil
+	}
+
+	f.classifier = model
+
+	return nil
+}
+
+// Probability of a news item being relevant, and whether it is confident enough to be a filter hit
+func (f *Fetcher) classify(newItem *JsonNewsItem) (float64, bool) {
+	if f.classifier == nil {
+		return 0, false
+	}
+
+	threshold := optional(f.Settings.Classifier.Threshold, DefaultClassifierThreshold)
+
+	probability := f.classifier.probability(newItem)
+
+	return probability, probability >= threshold
+}
+
+// Train Train the classifier with the stored news items linking to the provided article or
+// discussion URLs. An item trained before with the other label is moved to the new one.
+func (f *Fetcher) Train(relevant, irrelevant []string) (int, error) {
+	if err := f.setUpRepository(); err != nil {
+		return 0, err
+	}
+
+	defer f.repository.Close()
+
+	var trained int
+
+	for _, batch := range []struct {
+		label string
+		links []string
+	}{{LabelRelevant, relevant}, {LabelIrrelevant, irrelevant}} {
+		for _, link := range batch.links {
+			if err := f.repository.TrainItem(link, batch.label); err != nil {
+				return trained, err
+			}
+
+			trained++
+		}
+	}
+
+	return trained, nil
+}
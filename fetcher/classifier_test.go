@@ -0,0 +1,172 @@
+package fetcher
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestItemFeatures(t *testing.T) {
+	features := itemFeatures(&JsonNewsItem{Title: "Rust 2.0: a Rust release", Url: "https://GitHub.com/rust",
+		By: "Steve"})
+
+	if strings.Join(features, " ") != "title:rust title:release domain:github.com by:steve" {
+		t.Errorf("Unexpected features, %v", features)
+	}
+}
+
+// Store the news items and train the classifier with them
+func trainItems(t *testing.T, repo *DataRepository, label string, titles ...string) {
+	t.Helper()
+
+	for _, title := range titles {
+		link := fmt.Sprintf("http://%s.example.com/%s", label, strings.ReplaceAll(title, " ", "-"))
+		item := DigestItem{id: time.Now().UnixNano(), newsTitle: title, newsUrl: link, createdAt: time.Now().Unix()}
+
+		if err := repo.UpdateItems(&[]DigestItem{item}); err != nil {
+			t.Fatalf("Could not store the item, %v", err)
+		}
+
+		if err := repo.TrainItem(link, label); err != nil {
+			t.Fatalf("Could not train the item, %v", err)
+		}
+	}
+}
+
+func TestClassifier(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		Filters:    []FilterItem{{Title: "Linux", Value: "linux"}},
+		Classifier: ClassifierConfig{Enabled: true, MinExamples: ptr[uint](2), Title: "Learned"},
+		Database:   Database{Driver: "sqlite3", Database: ":memory:"},
+	}}
+
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
+
+	if err := fetcher.setUpRepository(); err != nil {
+		t.Fatalf("Error while initializing the repository, %v", err)
+	}
+
+	defer fetcher.repository.Close()
+
+	trainItems(t, &fetcher.repository, LabelRelevant, "Rust compiler internals", "Writing a compiler in Rust")
+
+	if err := fetcher.loadClassifier(&fetcher.repository); err != nil || fetcher.classifier != nil {
+		t.Fatalf("Expected the classifier not to be used before it has enough examples, %v", err)
+	}
+
+	trainItems(t, &fetcher.repository, LabelIrrelevant, "Celebrity gossip of the week", "Stock market news today")
+
+	if err := fetcher.loadClassifier(&fetcher.repository); err != nil || fetcher.classifier == nil {
+		t.Fatalf("Expected the classifier to be trained, %v", err)
+	}
+
+	relevant := JsonNewsItem{Title: "A new Rust compiler", Url: "http://relevant.example.com/new"}
+	irrelevant := JsonNewsItem{Title: "Market gossip", Url: "http://irrelevant.example.com/gossip"}
+
+	if probability, hit := fetcher.classify(&relevant); !hit {
+		t.Errorf("Expected the Rust item to be relevant, got %f", probability)
+	}
+
+	if probability, hit := fetcher.classify(&irrelevant); hit || probability > 0.5 {
+		t.Errorf("Expected the gossip item to be irrelevant, got %f", probability)
+	}
+
+	matched := fetcher.matchingFilters(&JsonNewsItem{Title: "Linux has a Rust compiler",
+		Url: "http://relevant.example.com/linux"})
+	if titles := filterTitles(matched); strings.Join(titles, ",") != "Linux,Learned" {
+		t.Errorf("Expected the classifier to hit alongside the filters, got %v", titles)
+	}
+
+	if groups := groupOrder(&fetcher.Settings); strings.Join(groups, ",") != "Linux,Learned" {
+		t.Errorf("Expected the classifier's group after the filters, got %v", groups)
+	}
+}
+
+func TestTrainItemRelabel(t *testing.T) {
+	repo := DataRepository{dbConfig: Database{Driver: "sqlite3", Database: ":memory:"}}
+
+	if err := repo.Init(); err != nil {
+		t.Fatalf("Error while preparing a test database in memory, %v", err)
+	}
+
+	defer repo.Close()
+
+	err := repo.UpdateItems(&[]DigestItem{{id: 1, newsTitle: "Rust news", newsUrl: "http://host/1",
+		discussionUrl: "http://host/item?id=1", createdAt: time.Now().Unix()}})
+	if err != nil {
+		t.Fatalf("Could not store the item, %v", err)
+	}
+
+	for _, label := range []string{LabelRelevant, LabelRelevant, LabelIrrelevant} {
+		if err := repo.TrainItem("http://host/item?id=1", label); err != nil {
+			t.Fatalf("Could not train the item, %v", err)
+		}
+	}
+
+	model, err := repo.LoadClassifier()
+	if err != nil {
+		t.Fatalf("Could not load the classifier, %v", err)
+	}
+
+	if model.items[LabelRelevant] != 0 || model.items[LabelIrrelevant] != 1 ||
+		model.counts[LabelIrrelevant]["title:rust"] != 1 || model.counts[LabelRelevant]["title:rust"] != 0 {
+		t.Errorf("Expected the item to be counted once, as irrelevant, got %v and %v", model.items, model.counts)
+	}
+
+	if err := repo.TrainItem("http://host/unknown", LabelRelevant); err == nil {
+		t.Errorf("Expected an error for a link no stored item has")
+	}
+}
+
+func TestClassifierPerMode(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "classifier.sqlite")
+	normal := DataRepository{dbConfig: Database{Driver: "sqlite3", Database: dbFile}, purgeAfter: 100000}
+	reverse := DataRepository{dbConfig: Database{Driver: "sqlite3", Database: dbFile}, purgeAfter: 100000,
+		reverse: true}
+
+	for _, repo := range []*DataRepository{&normal, &reverse} {
+		if err := repo.Init(); err != nil {
+			t.Fatalf("Error while preparing a test database, %v", err)
+		}
+
+		defer repo.Close()
+
+		err := repo.UpdateItems(&[]DigestItem{{id: 1, newsTitle: "Rust news", newsUrl: "http://host/1",
+			createdAt: time.Now().Unix()}})
+		if err != nil {
+			t.Fatalf("Could not store the item, %v", err)
+		}
+	}
+
+	if err := normal.TrainItem("http://host/1", LabelRelevant); err != nil {
+		t.Fatalf("Could not train the item, %v", err)
+	}
+
+	if err := reverse.TrainItem("http://host/1", LabelIrrelevant); err != nil {
+		t.Fatalf("Could not train the item, %v", err)
+	}
+
+	// The same item trained in both modes counts once in each mode's model
+	for repo, label := range map[*DataRepository]string{&normal: LabelRelevant, &reverse: LabelIrrelevant} {
+		model, err := repo.LoadClassifier()
+		if err != nil {
+			t.Fatalf("Could not load the classifier, %v", err)
+		}
+
+		if model.items[label] != 1 || model.items[LabelRelevant]+model.items[LabelIrrelevant] != 1 {
+			t.Errorf("Expected the item counted once as %s, got %v", label, model.items)
+		}
+	}
+}
+
+func TestInvalidClassifierThreshold(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{Classifier: ClassifierConfig{Enabled: true, Threshold: ptr(1.5)}}}
+
+	if err := fetcher.prepareFilters(); err == nil {
+		t.Errorf("Expected the threshold over 1 to be an error")
+	}
+}
@@ -0,0 +1,169 @@
+package fetcher
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/exp/slices"
+)
+
+// Near-duplicate title defaults, used unless configured in "Dedup"
+const (
+	// Share of the title shingles two stories have in common to be taken for the same one
+	DefaultTitleSimilarity = 0.5
+	// Days a delivered story suppresses the similar ones
+	DefaultSimilarTitleDays = 3
+)
+
+// Words too common in the titles to tell the stories apart
+var TitleStopWords = []string{
+	"an", "and", "are", "as", "at", "be", "by", "for", "from", "has", "how", "in", "is", "it", "its", "new", "of",
+	"on", "or", "the", "to", "vs", "was", "what", "why", "with", "ask", "show", "hn",
+}
+
+// Words of the titles, with the dotted numbers, like the versions, kept whole
+var titleWord = regexp.MustCompile(`\p{N}+(?:\.\p{N}+)+|[\p{L}\p{N}]+`)
+
+// Token shingles of a title: the pairs of its adjacent words, so that the titles differing in a
+// word that matters, like "Linux 6.10 released" and "Linux 6.11 released", have none in common.
+// The words are lower-cased, without the stop words and the single letters; a title of one word
+// is a shingle of its own.
+type titleShingles map[string]bool
+
+func shingles(title string) titleShingles {
+	var words []string
+
+	for _, word := range titleWord.FindAllString(strings.ToLower(title), -1) {
+		first, _ := utf8.DecodeRuneInString(word)
+
+		if unicode.IsDigit(first) || (utf8.RuneCountInString(word) > 1 && !slices.Contains(TitleStopWords, word)) {
+			words = append(words, word)
+		}
+	}
+
+	result := titleShingles{}
+
+	if len(words) == 1 {
+		result[words[0]] = true
+	}
+
+	for idx := 1; idx < len(words); idx++ {
+		result[words[idx-1]+" "+words[idx]] = true
+	}
+
+	return result
+}
+
+// Jaccard similarity of the shingles, the number of the common ones over the number of all
+func (a titleShingles) similarity(b titleShingles) float64 {
+	var common int
+
+	for shingle := range a {
+		if b[shingle] {
+			common++
+		}
+	}
+
+	all := len(a) + len(b) - common
+	if all == 0 {
+		return 0
+	}
+
+	return float64(common) / float64(all)
+}
+
+// Check the near-duplicate title settings
+func validateDedup(settings *DedupConfig) error {
+	if settings.TitleSimilarity < 0 || settings.TitleSimilarity > 1 {
+		return fmt.Errorf("title similarity %v is not between 0 and 1", settings.TitleSimilarity)
+	}
+
+	return nil
+}
+
+// Whether the titles are similar enough to be taken for the same story
+func (f *Fetcher) similar(a, b titleShingles) bool {
+	threshold := f.Settings.Dedup.TitleSimilarity
+	if threshold == 0 {
+		threshold = DefaultTitleSimilarity
+	}
+
+	return a.similarity(b) >= threshold
+}
+
+// Load the titles delivered within the configured days, to suppress the similar stories
+func (f *Fetcher) loadDeliveredTitles() error {
+	f.deliveredTitles = nil
+
+	days := optional(f.Settings.Dedup.SimilarTitleDays, DefaultSimilarTitleDays)
+	if days == 0 {
+		return nil
+	}
+
+	titles, err := f.repository.DeliveredTitles(time.Now().AddDate(0, 0, -int(days)))
+	if err != nil {
+		return err
+	}
+
+	f.deliveredTitles = make([]titleShingles, 0, len(titles))
+	for _, title := range titles {
+		f.deliveredTitles = append(f.deliveredTitles, shingles(title))
+	}
+
+	return nil
+}
+
+// Whether a story with a similar title has been delivered lately
+func (f *Fetcher) similarToDelivered(title string) bool {
+	itemShingles := shingles(title)
+
+	return slices.ContainsFunc(f.deliveredTitles, func(delivered titleShingles) bool {
+		return f.similar(itemShingles, delivered)
+	})
+}
+
+// Cluster the digest items with similar titles, so that every story is one entry, the most
+// relevant of its items, with the others listed as related. A title joins a cluster if it is
+// similar to any of the cluster's titles. The clusters keep the order of their first items.
+func (f *Fetcher) clusterDigest(digest []DigestItem) []DigestItem {
+	var (
+		clusters []DigestItem
+		members  [][]titleShingles
+	)
+
+	for _, item := range digest {
+		itemShingles := shingles(item.newsTitle)
+
+		idx := slices.IndexFunc(members, func(cluster []titleShingles) bool {
+			return slices.ContainsFunc(cluster, func(member titleShingles) bool {
+				return f.similar(itemShingles, member)
+			})
+		})
+
+		if idx < 0 {
+			clusters = append(clusters, item)
+			members = append(members, []titleShingles{itemShingles})
+
+			continue
+		}
+
+		members[idx] = append(members[idx], itemShingles)
+		head := clusters[idx]
+
+		if item.relevance <= head.relevance {
+			clusters[idx].related = append(head.related, item)
+			continue
+		}
+
+		// The more relevant item leads the cluster, followed by the former lead and its related items
+		item.related = append([]DigestItem{head}, head.related...)
+		item.related[0].related = nil
+		clusters[idx] = item
+	}
+
+	return clusters
+}
@@ -0,0 +1,130 @@
+package fetcher
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTitleSimilarity(t *testing.T) {
+	announced := shingles("Apple announces the M5 chip")
+
+	if similarity := announced.similarity(shingles("Apple announces M5 chip for the MacBook Pro")); similarity != 0.6 {
+		t.Errorf("Expected 3 of 5 shingles in common, got %f", similarity)
+	}
+
+	if similarity := announced.similarity(shingles("Show HN: A Rust compiler")); similarity != 0 {
+		t.Errorf("Expected no shingles in common, got %f", similarity)
+	}
+
+	if similarity := shingles("The").similarity(shingles("A")); similarity != 0 {
+		t.Errorf("Expected the titles of stop words only not to be similar, got %f", similarity)
+	}
+
+	if similarity := shingles("Rust").similarity(shingles("rust")); similarity != 1 {
+		t.Errorf("Expected the titles of one word to be compared by the word, got %f", similarity)
+	}
+}
+
+func TestSimilarTitles(t *testing.T) {
+	fetcher := Fetcher{}
+
+	for _, titles := range [][2]string{
+		{"Linux 6.10 released", "Linux 6.11 released"},
+		{"Linux 6.10 is out with the new scheduler", "Linux 6.11 is out with the new scheduler"},
+		{"Go 1.22 released", "Go 1.23 released"},
+		{"Rust 2.0 released", "Python 2.0 released"},
+	} {
+		if fetcher.similar(shingles(titles[0]), shingles(titles[1])) {
+			t.Errorf("Expected %q and %q not to be similar", titles[0], titles[1])
+		}
+	}
+
+	if !fetcher.similar(shingles("Linux 6.10 is out with the new scheduler"),
+		shingles("The new scheduler of Linux 6.10 is out")) {
+		t.Errorf("Expected the reordered titles of the same version to be similar")
+	}
+}
+
+func TestClusterDigest(t *testing.T) {
+	fetcher := Fetcher{}
+	digest := []DigestItem{
+		{id: 1, newsTitle: "Apple announces the M5 chip", relevance: 10},
+		{id: 2, newsTitle: "Rust 2.0 released", relevance: 20},
+		{id: 3, newsTitle: "Apple announces M5 chip for the MacBook Pro", relevance: 30},
+		{id: 4, newsTitle: "The M5 chip: Apple announces", relevance: 5},
+	}
+
+	clusters := fetcher.clusterDigest(digest)
+
+	if len(clusters) != 2 || clusters[0].id != 3 || clusters[1].id != 2 {
+		t.Fatalf("Expected the most relevant item to lead the Apple cluster, got %v", clusters)
+	}
+
+	if related := clusters[0].related; len(related) != 2 || related[0].id != 1 || related[1].id != 4 ||
+		len(related[0].related) != 0 {
+		t.Errorf("Expected the other Apple items to be related, got %v", related)
+	}
+
+	if ids := digestIDs(clusters); len(ids) != 4 {
+		t.Errorf("Expected the related items to be delivered too, got %v", ids)
+	}
+
+	fetcher.Settings.Dedup.TitleSimilarity = 1
+
+	if clusters := fetcher.clusterDigest(digest); len(clusters) != 4 {
+		t.Errorf("Expected no clusters of different titles with the similarity of 1, got %v", clusters)
+	}
+}
+
+func TestSimilarToDelivered(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		Filters:  []FilterItem{{Title: "Apple", Value: "apple"}},
+		Dedup:    DedupConfig{SimilarTitleDays: ptr[uint](2)},
+		Database: Database{Driver: "sqlite3", Database: ":memory:"},
+	}}
+
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
+
+	if err := fetcher.setUpRepository(); err != nil {
+		t.Fatalf("Error while initializing the repository, %v", err)
+	}
+
+	defer fetcher.repository.Close()
+
+	err := fetcher.repository.UpdateItems(&[]DigestItem{{id: 1, newsTitle: "Apple announces the M5 chip",
+		newsUrl: "https://apple.com/m5", createdAt: time.Now().Unix(), status: StatusDelivered}})
+	if err != nil {
+		t.Fatalf("Could not store the delivered item, %v", err)
+	}
+
+	if err := fetcher.loadDeliveredTitles(); err != nil {
+		t.Fatalf("Could not load the delivered titles, %v", err)
+	}
+
+	source := &staticSource{items: []JsonNewsItem{
+		{Id: 2, Title: "Apple announces M5 chip for the MacBook Pro", Url: "https://news.com/m5"},
+		{Id: 3, Title: "Apple opens a new store", Url: "https://news.com/store"},
+	}}
+
+	digest, err := fetcher.runSource(source)
+	if err != nil {
+		t.Fatalf("Error while running the source, %v", err)
+	}
+
+	if len(digest) != 1 || digest[0].id != 3 {
+		t.Errorf("Expected the story delivered under another title to be suppressed, got %v", digest)
+	}
+
+	fetcher.Settings.Dedup.SimilarTitleDays = ptr[uint](0)
+
+	if err := fetcher.loadDeliveredTitles(); err != nil || fetcher.similarToDelivered("Apple announces the M5 chip") {
+		t.Errorf("Expected no stories suppressed with 0 days, %v", err)
+	}
+
+	invalid := Fetcher{Settings: Configuration{Dedup: DedupConfig{TitleSimilarity: 2}}}
+	if err := invalid.prepareFilters(); err == nil {
+		t.Errorf("Expected the title similarity over 1 to be an error")
+	}
+}
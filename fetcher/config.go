@@ -4,6 +4,11 @@ import (
 	"github.com/tkanos/gonfig"
 )
 
+// Story lists provided by the HackerNews API
+var StoryLists = []string{"topstories", "newstories", "beststories", "askstories", "showstories", "jobstories"}
+
+const DefaultStoryList = "topstories"
+
 type SmtpConfig struct {
 	Host     string
 	From     string
@@ -28,15 +33,96 @@ type Database struct {
 	Address  string
 }
 
+type FetchConfig struct {
+	Concurrency uint
+	MaxRetries  uint
+	Lists       []string
+}
+
+type PendingConfig struct {
+	WindowHours       uint
+	BackoffMinutes    uint
+	MaxBackoffMinutes uint
+}
+
+type SourceConfig struct {
+	Type           string
+	Name           string
+	Url            string
+	Lists          []string
+	Subreddits     []string
+	Queries        []string
+	Tags           string
+	NumericFilters string
+	HoursBack      uint
+}
+
+type DigestConfig struct {
+	GroupOrder   []string
+	MaxItems     uint
+	MinRelevance float64
+	// Not set means the defaults; 0 leaves the points, the comments or the age out
+	ScoreWeight    *float64
+	CommentsWeight *float64
+	Gravity        *float64
+}
+
+// Not set means the defaults; 0 days turn the checks against the delivered items off
+type DedupConfig struct {
+	WindowDays       *uint
+	TitleSimilarity  float64
+	SimilarTitleDays *uint
+}
+
+type ClassifierConfig struct {
+	Enabled bool
+	Title   string
+	// Not set means the defaults
+	Threshold   *float64
+	MinExamples *uint
+	Weight      float64
+}
+
+type HttpConfig struct {
+	UserAgent      string
+	Proxy          string
+	TimeoutSeconds uint
+	// Not set means the default; 0 turns the retries off
+	Retries                *uint
+	BackoffMilliseconds    uint
+	MaxBackoffMilliseconds uint
+	RequestsPerSecond      float64
+}
+
 type Configuration struct {
 	ApiBaseUrl         string
+	Fetch              FetchConfig
 	EmailTo            string
 	Filters            []FilterItem
 	BlacklistedDomains []string
+	WhitelistedDomains []string
 	Database           Database
 	Smtp               SmtpConfig
 	Telegram           TelegramConfig
 	PurgeAfterDays     uint
+	MinScore           int64
+	MinComments        int64
+	MaxAgeHours        uint
+	Pending            PendingConfig
+	Sources            []SourceConfig
+	Http               HttpConfig
+	Digest             DigestConfig
+	Classifier         ClassifierConfig
+	Dedup              DedupConfig
+}
+
+// Value of a setting that can be left out, or the default if it is
+func optional[T any](value *T, defaultValue T) T {
+	if value == nil {
+		return defaultValue
+	}
+
+	return *value
 }
 
 func GetConfig(filename string) (Configuration, error) {
@@ -47,7 +133,15 @@ func GetConfig(filename string) (Configuration, error) {
 		return Configuration{}, err
 	}
 
-	if len(config.Filters) == 0 {
+	if len(config.Filters) == 0 && !config.Classifier.Enabled {
+		return Configuration{}, err
+	}
+
+	if err := (&Fetcher{Settings: config}).prepareFilters(); err != nil {
+		return Configuration{}, err
+	}
+
+	if _, err := newSources(&config); err != nil {
 		return Configuration{}, err
 	}
 
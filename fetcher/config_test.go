@@ -1,9 +1,17 @@
 package fetcher
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
+// Pointer to a value, for the settings that can be left out
+func ptr[T any](value T) *T {
+	return &value
+}
+
 func TestGetConfiguration(t *testing.T) {
 	cfg, err := GetConfig("../config.example.json")
 	if err != nil {
@@ -30,6 +38,10 @@ func TestGetConfiguration(t *testing.T) {
 		t.Fatalf("Database password [%s] value is wrong", cfg.Database.Password)
 	}
 
+	if cfg.Http.Retries == nil || *cfg.Http.Retries != 3 {
+		t.Fatalf("Http retries value %v is wrong", cfg.Http.Retries)
+	}
+
 	if cfg.Database.Database != "./hackernews_db.sqlite" {
 		t.Fatalf("DatabaseFile value [%s] is wrong", cfg.Database.Database)
 	}
@@ -68,3 +80,17 @@ func TestMissingFileGetConfiguration(t *testing.T) {
 		t.Fatal("Wrong configuration could be loaded")
 	}
 }
+
+func TestInvalidFilterGetConfiguration(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.json")
+	config := `{"Filters": [{"Title": "SQL", "Value": "sql"}, {"Title": "Broken", "Value": "[a-z"}]}`
+
+	if err := os.WriteFile(filename, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := GetConfig(filename)
+	if err == nil || !strings.Contains(err.Error(), `filter "Broken" (#2)`) {
+		t.Fatalf("Expected the broken filter to be reported, got %v", err)
+	}
+}
@@ -1,35 +1,270 @@
 package fetcher
 
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
 // Data Types
 
 type FilterItem struct {
-	Title string
-	Value string
+	Title       string
+	Value       string
+	Expression  string
+	Exclude     []string
+	Fields      []string
+	Weight      float64
+	MinScore    int64
+	MinComments int64
+	MaxAgeHours uint
+}
+
+// Score, comment count and age limits an item has to meet to get into the digest
+type Thresholds struct {
+	MinScore    int64
+	MinComments int64
+	MaxAgeHours uint
 }
 
+// Outcome of running a news item through the filters and thresholds
+type verdict int
+
+const (
+	// Not wanted in the digest, only recorded as seen
+	verdictSkip verdict = iota
+	// Goes into the digest
+	verdictInclude
+	// Matches the filters, but does not meet the thresholds yet; checked again on later runs
+	verdictDefer
+	// Matches the filters, but got too old before meeting the thresholds
+	verdictExpire
+)
+
 type PrefetchResults []int64
 
 type DigestItem struct {
 	newsTitle string
 	newsUrl   string
+	newsText  string
+	// Comments page of the item, if the source has one
+	discussionUrl string
+	// Link without the tracking parameters and the other differences of the reposts
+	canonicalUrl string
+	author       string
+	itemType     string
+	status       string
+	source       string
+	lists        []string
+	tags         []string
+	// Titles of the filters the item matched
+	filters []string
+	// Items of the same story under similar titles, listed with the item in the digest
+	related   []DigestItem
+	relevance float64
 	id        int64
 	createdAt int64
+	score     int64
+	comments  int64
+}
+
+// Name of the source the item came from, HackerNews for the items stored before sources were added
+func (item *DigestItem) sourceName() string {
+	if item.source == "" {
+		return HackerNewsSourceType
+	}
+
+	return item.source
+}
+
+// Points, author, comment count and tags of the item, e.g. "289 points by pg, 12 comments"
+func (item *DigestItem) statsLabel() string {
+	label := fmt.Sprintf("%d points", item.score)
+
+	if item.author != "" {
+		label += " by " + item.author
+	}
+
+	label = fmt.Sprintf("%s, %d comments", label, item.comments)
+
+	if len(item.tags) > 0 {
+		label += ", tagged " + strings.Join(item.tags, ", ")
+	}
+
+	return label
+}
+
+// Link to the item's comments, unless it is the item's own link, as for Ask HN posts
+func (item *DigestItem) discussionLink() string {
+	if item.discussionUrl == item.newsUrl {
+		return ""
+	}
+
+	return item.discussionUrl
+}
+
+// Link to where the item is discussed: its comments page, or its own link if it has none
+func (item *DigestItem) discussedAt() string {
+	if item.discussionUrl != "" {
+		return item.discussionUrl
+	}
+
+	return item.newsUrl
+}
+
+// Label of the links to the related items for the text outputs, e.g. " - also discussed at: https://..."
+func (item *DigestItem) relatedLabel() string {
+	if len(item.related) == 0 {
+		return ""
+	}
+
+	links := make([]string, 0, len(item.related))
+	for idx := range item.related {
+		links = append(links, item.related[idx].discussedAt())
+	}
+
+	return " - also discussed at: " + strings.Join(links, ", ")
+}
+
+// Label of the link to the item's comments for the text outputs, e.g. " - discussion: https://..."
+func (item *DigestItem) discussionLabel() string {
+	if item.discussionLink() == "" {
+		return ""
+	}
+
+	return " - discussion: " + item.discussionLink()
+}
+
+// Short names of the story lists the item came from, e.g. "top, best"
+func (item *DigestItem) listNames() string {
+	names := make([]string, 0, len(item.lists))
+
+	for _, list := range item.lists {
+		names = append(names, strings.TrimSuffix(list, "stories"))
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// Label of the story lists the item came from, e.g. "[top, best] "
+func (item *DigestItem) listsLabel() string {
+	if len(item.lists) == 0 {
+		return ""
+	}
+
+	return "[" + item.listNames() + "] "
 }
 
 type JsonNewsItem struct {
-	Title string `json:"title,omitempty"`
-	Url   string `json:"url,omitempty"`
-	Id    int64  `json:"id"`
-	Time  int64  `json:"time"`
+	Title       string `json:"title,omitempty"`
+	Url         string `json:"url,omitempty"`
+	By          string `json:"by,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Text        string `json:"text,omitempty"`
+	Id          int64  `json:"id"`
+	Time        int64  `json:"time"`
+	Score       int64  `json:"score"`
+	Descendants int64  `json:"descendants"`
+	// Set by the source the item came from
+	Source     string   `json:"-"`
+	Discussion string   `json:"-"`
+	Lists      []string `json:"-"`
+	Tags       []string `json:"-"`
+}
+
+// A news item as stored in the repository
+type storedItem struct {
+	Title      string         `db:"news_title"`
+	Url        string         `db:"news_url"`
+	Author     string         `db:"author"`
+	Type       string         `db:"item_type"`
+	Text       sql.NullString `db:"news_text"`
+	Source     string         `db:"source"`
+	Discussion sql.NullString `db:"discussion_url"`
+	Id         int64          `db:"id"`
+	CreatedAt  int64          `db:"created_at"`
+	Score      int64          `db:"score"`
+	Comments   int64          `db:"comments"`
+}
+
+// The stored item as it came from its source, so that it can be run through the filters again
+func (item *storedItem) newsItem() JsonNewsItem {
+	return JsonNewsItem{
+		Title:       item.Title,
+		Url:         item.Url,
+		By:          item.Author,
+		Type:        item.Type,
+		Text:        item.Text.String,
+		Id:          item.Id,
+		Time:        item.CreatedAt,
+		Score:       item.Score,
+		Descendants: item.Comments,
+		Source:      item.Source,
+		Discussion:  item.Discussion.String,
+	}
 }
 
 type Digest []DigestItem
 
-type FetchError struct{}
+type fetchResult struct {
+	item JsonNewsItem
+	err  error
+}
+
+type fetchFailure struct {
+	err    error
+	id     int64
+	source string
+}
+
+// FetchError A non-200 response to an HTTP request
+type FetchError struct {
+	Url        string
+	Status     string
+	StatusCode int
+}
+
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("%s responded with %s", e.Url, e.Status)
+}
+
+// ErrNotListed The item has dropped off its source's listing, which is all the source can serve,
+// so it cannot be fetched anymore
+var ErrNotListed = errors.New("not listed anymore")
+
+// FilterError A filter pattern that could not be compiled, or an expression that could not be parsed
+type FilterError struct {
+	Title           string
+	Kind            string
+	Pattern         string
+	Err             error
+	Position        int
+	PatternPosition int
+}
+
+// The patterns are quoted as they are, without escaping the backslashes of the regexes
+func (e *FilterError) Error() string {
+	// Expressions are not split into patterns
+	if e.PatternPosition == 0 {
+		return fmt.Sprintf("filter %q (#%d), %s \"%s\": %v", e.Title, e.Position, e.Kind, e.Pattern, e.Err)
+	}
+
+	return fmt.Sprintf("filter %q (#%d), %s \"%s\" (#%d): %v", e.Title, e.Position, e.Kind, e.Pattern,
+		e.PatternPosition, e.Err)
+}
+
+func (e *FilterError) Unwrap() error {
+	return e.Err
+}
 
 type Results struct {
 	NewItems int
 	Filters  int
+	Deferred int
+	Failed   int
+	// Stored items delivered by the re-filtering
+	Delivered int
 }
 
 // Constants
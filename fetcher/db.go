@@ -1,11 +1,15 @@
 package fetcher
 
 import (
-	"github.com/jmoiron/sqlx"
-
+	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
 )
 
 // Constants
@@ -17,27 +21,154 @@ const (
 	id INTEGER PRIMARY KEY,
 	created_at INTEGER NOT NULL,
 	news_title TEXT NOT NULL,
-	news_url  TEXT NOT NULL
+	news_url  TEXT NOT NULL,
+	score INTEGER NOT NULL DEFAULT 0,
+	author VARCHAR(255) NOT NULL DEFAULT '',
+	comments INTEGER NOT NULL DEFAULT 0,
+	item_type VARCHAR(32) NOT NULL DEFAULT '',
+	news_text TEXT NULL,
+	status VARCHAR(16) NOT NULL DEFAULT 'seen',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_check_at INTEGER NOT NULL DEFAULT 0,
+	source VARCHAR(32) NOT NULL DEFAULT 'hackernews',
+	discussion_url TEXT NULL,
+	delivered_at INTEGER NOT NULL DEFAULT 0,
+	trained_as VARCHAR(16) NOT NULL DEFAULT '',
+	canonical_url TEXT NULL
+)`
+
+	// Every mode has a model of its own, like the news items it is trained with
+	ClassifierTableName   = "classifier_features"
+	CreateClassifierTable = `CREATE TABLE IF NOT EXISTS %s
+(
+	feature VARCHAR(255) NOT NULL,
+	label VARCHAR(16) NOT NULL,
+	items INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (feature, label)
 )`
 
-	DblCrLf          = CRLF + CRLF
-	SQLiteVacuum     = "VACUUM"
-	MySQLVacuum      = "SELECT 1"
-	SelectItems      = "SELECT id FROM %s"
-	InsertItems      = "INSERT INTO %s VALUES (?,?,?,?)"
-	SQLitePurgeItems = "DELETE FROM %s WHERE date(created_at, \"unixepoch\", \"localtime\") < date(\"now\", \"-%d days\")"
-	MySQLPurgeItems  = "DELETE FROM %s WHERE FROM_UNIXTIME(created_at) <= (NOW() - INTERVAL %d DAY)"
+	RetriesTableName   = "fetch_retries"
+	CreateRetriesTable = `CREATE TABLE IF NOT EXISTS %s
+(
+	id INTEGER PRIMARY KEY,
+	source VARCHAR(32) NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT NULL,
+	updated_at INTEGER NOT NULL
+)`
+
+	DblCrLf      = CRLF + CRLF
+	SQLiteVacuum = "VACUUM"
+	MySQLVacuum  = "SELECT 1"
+	SelectItems  = "SELECT id FROM %s WHERE (source IN (?) OR id >= ?) AND id IN (?)"
+	InsertItems  = "REPLACE INTO %s (id, created_at, news_title, news_url, score, author, comments, item_type, " +
+		"news_text, status, attempts, next_check_at, source, discussion_url, delivered_at, canonical_url) " +
+		"VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)"
+	StoredItemColumns = "id, created_at, news_title, news_url, score, author, comments, item_type, news_text, " +
+		"source, discussion_url"
+	SelectStoredItems = "SELECT " + StoredItemColumns + " FROM %s WHERE news_title <> ? ORDER BY created_at, id"
+	// Never delivered items, except the pending ones, which are checked again on the regular runs
+	SelectUndeliveredItems = "SELECT " + StoredItemColumns + " FROM %s WHERE news_title <> ? AND created_at >= ? " +
+		"AND delivered_at = 0 AND status <> ? ORDER BY created_at, id"
+	SelectTrainingItem = "SELECT " + StoredItemColumns + ", trained_as FROM %s " +
+		"WHERE news_url = ? OR discussion_url = ? ORDER BY created_at DESC LIMIT 1"
+	SelectDelivered       = "SELECT COUNT(*) FROM %s WHERE canonical_url = ? AND delivered_at >= ?"
+	SelectDeliveredTitles = "SELECT news_title FROM %s WHERE delivered_at >= ?"
+	UpdateTrainedAs       = "UPDATE %s SET trained_as = ? WHERE id = ?"
+	SelectLinks           = "SELECT id, news_url FROM %s WHERE canonical_url IS NULL"
+	UpdateCanonicalURL    = "UPDATE %s SET canonical_url = ? WHERE id = ?"
+	SelectClassifier      = "SELECT feature, label, items FROM %s"
+	SelectFeatureCount    = "SELECT items FROM %s WHERE feature = ? AND label = ?"
+	InsertFeature         = "REPLACE INTO %s (feature, label, items) VALUES (?,?,?)"
+	SelectAttempts        = "SELECT attempts FROM %s WHERE id = ? AND status = ?"
+	SelectDueItems        = "SELECT id FROM %s WHERE source = ? AND status = ? AND next_check_at <= ?"
+	UpdateStatus          = "UPDATE %s SET status = ?, delivered_at = ? WHERE id IN (?)"
+	ExpirePending         = "UPDATE %s SET status = ? WHERE status = ? AND id IN (?)"
+	SelectRetries         = "SELECT id FROM %s WHERE source = ? AND attempts < ?"
+	SelectGivenUp         = "SELECT id FROM %s WHERE attempts >= ? AND source IN (?) AND id IN (?)"
+	SelectRetryCount      = "SELECT attempts FROM %s WHERE id = ?"
+	InsertRetry           = "REPLACE INTO %s (id, source, attempts, last_error, updated_at) VALUES (?,?,?,?,?)"
+	DeleteRetries         = "DELETE FROM %s WHERE id IN (?)"
+	ProbeColumn           = "SELECT %s FROM %s LIMIT 1"
+	SQLiteCreateIndex     = "CREATE INDEX IF NOT EXISTS %s ON %s (%s)"
+	// MySQL indexes only a prefix of a TEXT column, and cannot skip an existing index
+	MySQLCreateIndex = "CREATE INDEX %s ON %s (%s(255))"
+	MySQLSelectIndex = "SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() " +
+		"AND table_name = ? AND index_name = ?"
+	AddColumn        = "ALTER TABLE %s ADD COLUMN %s %s"
+	SQLitePurgeItems = "DELETE FROM %s WHERE date(created_at, \"unixepoch\", \"localtime\") < " +
+		"date(\"now\", \"-%d days\")"
+	MySQLPurgeItems    = "DELETE FROM %s WHERE FROM_UNIXTIME(created_at) <= (NOW() - INTERVAL %d DAY)"
+	SQLitePurgeRetries = "DELETE FROM %s WHERE date(updated_at, \"unixepoch\", \"localtime\") < " +
+		"date(\"now\", \"-%d days\")"
+	MySQLPurgeRetries = "DELETE FROM %s WHERE FROM_UNIXTIME(updated_at) <= (NOW() - INTERVAL %d DAY)"
 )
 
+// MySQL's error number of an unknown column
+const MySQLBadFieldError = 1054
+
 var PurgeItems string
+var PurgeRetries string
 var Vacuum string
+var CreateIndex string
+
+// Columns of the news items table looked up by value, e.g. the canonical URLs of the reposts
+var IndexedColumns = []string{"canonical_url"}
+
+// Columns added to the news items table after its first release. Databases created
+// by older versions get them added on start-up, and filled in by the backfill statement or
+// function, if any.
+var Migrations = []struct {
+	Column     string
+	Definition string
+	Backfill   string
+	Fill       func(repo *DataRepository, tableName string) error
+}{
+	{Column: "score", Definition: "INTEGER NOT NULL DEFAULT 0"},
+	{Column: "author", Definition: "VARCHAR(255) NOT NULL DEFAULT ''"},
+	{Column: "comments", Definition: "INTEGER NOT NULL DEFAULT 0"},
+	{Column: "item_type", Definition: "VARCHAR(32) NOT NULL DEFAULT ''"},
+	{Column: "news_text", Definition: "TEXT NULL"},
+	{Column: "status", Definition: "VARCHAR(16) NOT NULL DEFAULT 'seen'"},
+	{Column: "attempts", Definition: "INTEGER NOT NULL DEFAULT 0"},
+	{Column: "next_check_at", Definition: "INTEGER NOT NULL DEFAULT 0"},
+	{Column: "source", Definition: "VARCHAR(32) NOT NULL DEFAULT 'hackernews'"},
+	{Column: "discussion_url", Definition: "TEXT NULL"},
+	// Older versions did not record which items they sent, so the items stored before, except the
+	// pending and the expired ones, are taken as delivered when they were created
+	{Column: "delivered_at", Definition: "INTEGER NOT NULL DEFAULT 0",
+		Backfill: "UPDATE %s SET delivered_at = created_at WHERE status NOT IN ('" + StatusPending + "', '" +
+			StatusExpired + "')"},
+	{Column: "trained_as", Definition: "VARCHAR(16) NOT NULL DEFAULT ''"},
+	{Column: "canonical_url", Definition: "TEXT NULL", Fill: (*DataRepository).fillCanonicalURLs},
+}
+
+// States of the stored news items
+const (
+	// Fetched, but not wanted in the digest
+	StatusSeen = "seen"
+	// Matches the filters, but does not meet the thresholds yet; re-checked on a backoff schedule
+	StatusPending = "pending"
+	// Sent out in a digest
+	StatusDelivered = "delivered"
+	// Was pending, but did not qualify before its window closed
+	StatusExpired = "expired"
+)
+
+const (
+	DefaultBackoffMinutes    = 30
+	DefaultMaxBackoffMinutes = 6 * 60
+	DefaultMaxFetchRetries   = 3
+)
 
 type DataRepository struct {
 	db         *sqlx.DB
 	tbl_prefix string
 	dbConfig   Database
+	pending    PendingConfig
 	reverse    bool
 	purgeAfter uint
+	maxRetries uint
 }
 
 // Remove news items older than `purgeAfter` days
@@ -48,11 +179,105 @@ func (repo *DataRepository) purgeOld() error {
 		return err
 	}
 
+	purgeStmt = fmt.Sprintf(PurgeRetries, repo.tbl_prefix+RetriesTableName, repo.purgeAfter)
+
+	if _, err := repo.db.Exec(purgeStmt); err != nil {
+		return err
+	}
+
 	_, err := repo.db.Exec(Vacuum)
 
 	return err
 }
 
+// Add the columns missing in a table created by an older version
+func (repo *DataRepository) migrate() error {
+	tableName := repo.tbl_prefix + TableName
+
+	for _, migration := range Migrations {
+		rows, err := repo.db.Query(fmt.Sprintf(ProbeColumn, migration.Column, tableName))
+		if err == nil {
+			rows.Close()
+			continue
+		}
+
+		if !isMissingColumn(err) {
+			return fmt.Errorf("could not check column %s of %s: %w", migration.Column, tableName, err)
+		}
+
+		addStmt := fmt.Sprintf(AddColumn, tableName, migration.Column, migration.Definition)
+
+		if _, err := repo.db.Exec(addStmt); err != nil {
+			return fmt.Errorf("could not add column %s to %s: %w", migration.Column, tableName, err)
+		}
+
+		if migration.Backfill != "" {
+			if _, err := repo.db.Exec(fmt.Sprintf(migration.Backfill, tableName)); err != nil {
+				return fmt.Errorf("could not fill in column %s of %s: %w", migration.Column, tableName, err)
+			}
+		}
+
+		if migration.Fill != nil {
+			if err := migration.Fill(repo, tableName); err != nil {
+				return fmt.Errorf("could not fill in column %s of %s: %w", migration.Column, tableName, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Fill in the canonical URLs of the items stored by older versions, so that their reposts are told
+func (repo *DataRepository) fillCanonicalURLs(tableName string) error {
+	var links []struct {
+		Id      int64  `db:"id"`
+		NewsUrl string `db:"news_url"`
+	}
+
+	if err := repo.db.Select(&links, fmt.Sprintf(SelectLinks, tableName)); err != nil {
+		return err
+	}
+
+	for _, link := range links {
+		if _, err := repo.db.Exec(fmt.Sprintf(UpdateCanonicalURL, tableName), canonicalURL(link.NewsUrl),
+			link.Id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Whether a query failed because of a missing column, and not e.g. because the database is locked
+func isMissingColumn(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == MySQLBadFieldError
+	}
+
+	var sqliteErr sqlite3.Error
+
+	return errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrError &&
+		strings.HasPrefix(sqliteErr.Error(), "no such column")
+}
+
+// Index a column of a table, unless it is indexed already
+func (repo *DataRepository) createIndex(tableName, column string) error {
+	indexName := tableName + "_" + column
+
+	if repo.dbConfig.Driver == "mysql" {
+		var count int64
+
+		if err := repo.db.Get(&count, MySQLSelectIndex, tableName, indexName); err != nil || count > 0 {
+			return err
+		}
+	}
+
+	_, err := repo.db.Exec(fmt.Sprintf(CreateIndex, indexName, tableName, column))
+
+	return err
+}
+
 // Open a database file and purge old news items from it
 func (repo *DataRepository) prepareDB() error {
 	var err error
@@ -60,14 +285,22 @@ func (repo *DataRepository) prepareDB() error {
 	switch repo.dbConfig.Driver {
 	case "sqlite3":
 		repo.db, err = sqlx.Open(repo.dbConfig.Driver, repo.dbConfig.Database)
+		if err == nil {
+			// Every connection to an in-memory database gets a database of its own
+			repo.db.SetMaxOpenConns(1)
+		}
 		PurgeItems = SQLitePurgeItems
+		PurgeRetries = SQLitePurgeRetries
 		Vacuum = SQLiteVacuum
+		CreateIndex = SQLiteCreateIndex
 	case "mysql":
 		repo.db, err = sqlx.Open(repo.dbConfig.Driver,
 			fmt.Sprintf("%s:%s@%s/%s", repo.dbConfig.Username,
 				repo.dbConfig.Password, repo.dbConfig.Address, repo.dbConfig.Database))
 		PurgeItems = MySQLPurgeItems
+		PurgeRetries = MySQLPurgeRetries
 		Vacuum = MySQLVacuum
+		CreateIndex = MySQLCreateIndex
 	default:
 		return fmt.Errorf("wrong repository driver")
 	}
@@ -80,6 +313,24 @@ func (repo *DataRepository) prepareDB() error {
 		return err
 	}
 
+	if err := repo.migrate(); err != nil {
+		return err
+	}
+
+	for _, column := range IndexedColumns {
+		if err := repo.createIndex(repo.tbl_prefix+TableName, column); err != nil {
+			return fmt.Errorf("could not index column %s: %w", column, err)
+		}
+	}
+
+	if _, err := repo.db.Exec(fmt.Sprintf(CreateRetriesTable, repo.tbl_prefix+RetriesTableName)); err != nil {
+		return err
+	}
+
+	if _, err := repo.db.Exec(fmt.Sprintf(CreateClassifierTable, repo.tbl_prefix+ClassifierTableName)); err != nil {
+		return err
+	}
+
 	if err := repo.purgeOld(); err != nil {
 		return err
 	}
@@ -110,47 +361,164 @@ func contains(list []int64, val int64) bool {
 	return false
 }
 
-// Pull existing news items' IDs
-func (repo *DataRepository) GetIDsToPull(prefetched *[]int64) ([]int64, error) {
+// Pull existing news items' IDs. Of the prefetched IDs, those not in the repository yet are
+// returned, followed by the source's pending items that are due for another check, and the
+// source's items that failed to be fetched before. Items that failed too many times are given up,
+// whether they are prefetched or pending. The prefetched IDs are looked up among the items of the
+// source and of the sharing sources, the sources whose items share their IDs, e.g. the sources
+// reading HackerNews. The made up IDs are unique to their source type or feed, so they are looked
+// up among the items of any source, e.g. those stored under the former name of a renamed feed.
+func (repo *DataRepository) GetIDsToPull(source string, prefetched *[]int64, sharing ...string) ([]int64, error) {
 	var (
 		itemsToCheck []int64
 		existingIDs  []int64
+		givenUpIDs   []int64
+		retryIDs     []int64
 	)
 
-	query, args, err := sqlx.In(fmt.Sprintf(SelectItems+" WHERE id IN (?)", repo.tbl_prefix+TableName), *prefetched)
+	sources := append([]string{source}, sharing...)
 
+	dueIDs, err := repo.getDueIDs(source)
 	if err != nil {
 		return itemsToCheck, err
 	}
 
-	rows, err := repo.db.Query(query, args...)
-
+	err = repo.db.Select(&retryIDs, fmt.Sprintf(SelectRetries, repo.tbl_prefix+RetriesTableName),
+		source, repo.maxFetchRetries())
 	if err != nil {
 		return itemsToCheck, err
 	}
 
-	defer rows.Close()
+	if len(*prefetched) > 0 {
+		query, args, err := sqlx.In(fmt.Sprintf(SelectItems, repo.tbl_prefix+TableName), sources, namespacedIDBit,
+			*prefetched)
 
-	for rows.Next() {
-		var curID int64
+		if err != nil {
+			return itemsToCheck, err
+		}
 
-		if err := rows.Scan(&curID); err != nil {
+		if err := repo.db.Select(&existingIDs, query, args...); err != nil {
 			return itemsToCheck, err
 		}
+	}
 
-		existingIDs = append(existingIDs, curID)
+	if candidates := append(append([]int64{}, *prefetched...), dueIDs...); len(candidates) > 0 {
+		query, args, err := sqlx.In(fmt.Sprintf(SelectGivenUp, repo.tbl_prefix+RetriesTableName),
+			repo.maxFetchRetries(), sources, candidates)
+		if err != nil {
+			return itemsToCheck, err
+		}
+
+		if err := repo.db.Select(&givenUpIDs, query, args...); err != nil {
+			return itemsToCheck, err
+		}
 	}
 
 	for _, p := range *prefetched {
-		if !contains(existingIDs, p) {
+		if !contains(existingIDs, p) && !contains(givenUpIDs, p) && !contains(itemsToCheck, p) {
 			itemsToCheck = append(itemsToCheck, p)
 		}
 	}
 
+	for _, id := range append(dueIDs, retryIDs...) {
+		if !contains(givenUpIDs, id) && !contains(itemsToCheck, id) {
+			itemsToCheck = append(itemsToCheck, id)
+		}
+	}
+
 	return itemsToCheck, nil
 }
 
-// Add the provided news items to the database
+// Number of failed fetches after which an item is given up
+func (repo *DataRepository) maxFetchRetries() uint {
+	if repo.maxRetries == 0 {
+		return DefaultMaxFetchRetries
+	}
+
+	return repo.maxRetries
+}
+
+// Time a news item of the status is delivered at, or 0 if it is not delivered
+func deliveredAt(status string) int64 {
+	if status != StatusDelivered {
+		return 0
+	}
+
+	return time.Now().Unix()
+}
+
+// Set the status of the stored news items, and when they were delivered
+func (repo *DataRepository) SetStatus(ids []int64, status string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query, args, err := sqlx.In(fmt.Sprintf(UpdateStatus, repo.tbl_prefix+TableName), status, deliveredAt(status),
+		ids)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.db.Exec(query, args...)
+
+	return err
+}
+
+// Record the failed fetches of the items, so that they are retried on the next runs of their sources.
+// The pending items that failed too many times expire.
+func (repo *DataRepository) RecordFailures(failures *[]fetchFailure) error {
+	tableName := repo.tbl_prefix + RetriesTableName
+
+	for _, failure := range *failures {
+		var attempts int64
+
+		err := repo.db.Get(&attempts, fmt.Sprintf(SelectRetryCount, tableName), failure.id)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if _, err := repo.db.Exec(fmt.Sprintf(InsertRetry, tableName), failure.id, failure.source, attempts+1,
+			failure.err.Error(), time.Now().Unix()); err != nil {
+			return err
+		}
+
+		if attempts+1 >= int64(repo.maxFetchRetries()) {
+			if err := repo.ExpireItems([]int64{failure.id}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Forget the failed fetches of the items that have been fetched now
+func (repo *DataRepository) ClearFailures(ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query, args, err := sqlx.In(fmt.Sprintf(DeleteRetries, repo.tbl_prefix+RetriesTableName), ids)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.db.Exec(query, args...)
+
+	return err
+}
+
+// IDs of the source's pending items whose next check time has come
+func (repo *DataRepository) getDueIDs(source string) ([]int64, error) {
+	var dueIDs []int64
+
+	err := repo.db.Select(&dueIDs, fmt.Sprintf(SelectDueItems, repo.tbl_prefix+TableName),
+		source, StatusPending, time.Now().Unix())
+
+	return dueIDs, err
+}
+
+// Add the provided news items to the database, or update the state of the pending ones
 func (repo *DataRepository) UpdateItems(newItems *[]DigestItem) error {
 	stmt, err := repo.db.Prepare(fmt.Sprintf(InsertItems, repo.tbl_prefix+TableName))
 
@@ -158,8 +526,177 @@ func (repo *DataRepository) UpdateItems(newItems *[]DigestItem) error {
 		return err
 	}
 
+	defer stmt.Close()
+
 	for _, newItem := range *newItems {
-		if _, err := stmt.Exec(newItem.id, newItem.createdAt, newItem.newsTitle, newItem.newsUrl); err != nil {
+		status := newItem.status
+		if status == "" {
+			status = StatusSeen
+		}
+
+		if _, err := stmt.Exec(newItem.id, newItem.createdAt, newItem.newsTitle, newItem.newsUrl,
+			newItem.score, newItem.author, newItem.comments, newItem.itemType, newItem.newsText,
+			status, 0, 0, newItem.sourceName(), newItem.discussionUrl, deliveredAt(status),
+			newItem.canonicalUrl); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delay between the checks of a pending item, doubled after every attempt
+func (repo *DataRepository) backoff(attempts int64) time.Duration {
+	initial := time.Duration(repo.pending.BackoffMinutes) * time.Minute
+	if initial == 0 {
+		initial = DefaultBackoffMinutes * time.Minute
+	}
+
+	limit := time.Duration(repo.pending.MaxBackoffMinutes) * time.Minute
+	if limit == 0 {
+		limit = DefaultMaxBackoffMinutes * time.Minute
+	}
+
+	delay := initial
+	for i := int64(1); i < attempts && delay < limit; i++ {
+		delay *= 2
+	}
+
+	return min(delay, limit)
+}
+
+// Store the provided news items as pending and schedule their next check
+func (repo *DataRepository) DeferItems(items *[]DigestItem) error {
+	tableName := repo.tbl_prefix + TableName
+
+	stmt, err := repo.db.Prepare(fmt.Sprintf(InsertItems, tableName))
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	for _, item := range *items {
+		var attempts int64
+
+		err := repo.db.Get(&attempts, fmt.Sprintf(SelectAttempts, tableName), item.id, StatusPending)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		attempts++
+		nextCheckAt := time.Now().Add(repo.backoff(attempts)).Unix()
+
+		if _, err := stmt.Exec(item.id, item.createdAt, item.newsTitle, item.newsUrl,
+			item.score, item.author, item.comments, item.itemType, item.newsText,
+			StatusPending, attempts, nextCheckAt, item.sourceName(), item.discussionUrl, 0,
+			item.canonicalUrl); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Expire the pending ones of the news items, so that they are not checked anymore
+func (repo *DataRepository) ExpireItems(ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query, args, err := sqlx.In(fmt.Sprintf(ExpirePending, repo.tbl_prefix+TableName), StatusExpired,
+		StatusPending, ids)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.db.Exec(query, args...)
+
+	return err
+}
+
+// Load the stored news items, oldest first, to run them through the filters again. The deleted
+// and dead items are stored without a title, so they are left out.
+func (repo *DataRepository) StoredItems() ([]JsonNewsItem, error) {
+	return repo.selectStored(fmt.Sprintf(SelectStoredItems, repo.tbl_prefix+TableName), "-")
+}
+
+// Load the stored news items created since the time that have never been delivered, oldest first
+func (repo *DataRepository) UndeliveredItems(since time.Time) ([]JsonNewsItem, error) {
+	return repo.selectStored(fmt.Sprintf(SelectUndeliveredItems, repo.tbl_prefix+TableName), "-", since.Unix(),
+		StatusPending)
+}
+
+// Load the stored news items selected by the query
+func (repo *DataRepository) selectStored(query string, args ...any) ([]JsonNewsItem, error) {
+	var stored []storedItem
+
+	if err := repo.db.Select(&stored, query, args...); err != nil {
+		return nil, err
+	}
+
+	items := make([]JsonNewsItem, 0, len(stored))
+
+	for idx := range stored {
+		items = append(items, stored[idx].newsItem())
+	}
+
+	return items, nil
+}
+
+// Whether an item linking to the canonical URL has been delivered since the time
+func (repo *DataRepository) DeliveredSince(canonicalURL string, since time.Time) (bool, error) {
+	var count int64
+
+	err := repo.db.Get(&count, fmt.Sprintf(SelectDelivered, repo.tbl_prefix+TableName), canonicalURL, since.Unix())
+
+	return count > 0, err
+}
+
+// Titles of the news items delivered since the time
+func (repo *DataRepository) DeliveredTitles(since time.Time) ([]string, error) {
+	var titles []string
+
+	err := repo.db.Select(&titles, fmt.Sprintf(SelectDeliveredTitles, repo.tbl_prefix+TableName), since.Unix())
+
+	return titles, err
+}
+
+// Load the classifier's model, built from the trained feature counts
+func (repo *DataRepository) LoadClassifier() (*classifierModel, error) {
+	var rows []struct {
+		Feature string `db:"feature"`
+		Label   string `db:"label"`
+		Items   int64  `db:"items"`
+	}
+
+	if err := repo.db.Select(&rows, fmt.Sprintf(SelectClassifier, repo.tbl_prefix+ClassifierTableName)); err != nil {
+		return nil, err
+	}
+
+	model := newClassifierModel()
+
+	for _, row := range rows {
+		model.add(row.Feature, row.Label, row.Items)
+	}
+
+	return model, nil
+}
+
+// Add to the counts of the items of a label that have the features
+func (repo *DataRepository) countFeatures(features []string, label string, items int64) error {
+	tableName := repo.tbl_prefix + ClassifierTableName
+
+	for _, feature := range features {
+		var count int64
+
+		err := repo.db.Get(&count, fmt.Sprintf(SelectFeatureCount, tableName), feature, label)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if _, err := repo.db.Exec(fmt.Sprintf(InsertFeature, tableName), feature, label,
+			max(count+items, 0)); err != nil {
 			return err
 		}
 	}
@@ -167,6 +704,43 @@ func (repo *DataRepository) UpdateItems(newItems *[]DigestItem) error {
 	return nil
 }
 
+// Train the classifier with the stored news item linking to the article or discussion URL.
+// An item trained before with the other label is taken out of that label's counts first.
+func (repo *DataRepository) TrainItem(link, label string) error {
+	var item struct {
+		storedItem
+		TrainedAs string `db:"trained_as"`
+	}
+
+	tableName := repo.tbl_prefix + TableName
+
+	err := repo.db.Get(&item, fmt.Sprintf(SelectTrainingItem, tableName), link, link)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no stored item links to %s", link)
+	}
+
+	if err != nil || item.TrainedAs == label {
+		return err
+	}
+
+	newsItem := item.newsItem()
+	features := append(itemFeatures(&newsItem), ClassifierItemsFeature)
+
+	if item.TrainedAs != "" {
+		if err := repo.countFeatures(features, item.TrainedAs, -1); err != nil {
+			return err
+		}
+	}
+
+	if err := repo.countFeatures(features, label, 1); err != nil {
+		return err
+	}
+
+	_, err = repo.db.Exec(fmt.Sprintf(UpdateTrainedAs, tableName), label, item.Id)
+
+	return err
+}
+
 // Close the database
 func (repo *DataRepository) Close() {
 	repo.db.Close()
@@ -1,7 +1,13 @@
 package fetcher
 
 import (
+	"errors"
+	"fmt"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
 )
 
 func TestPrepareRepository(t *testing.T) {
@@ -44,7 +50,7 @@ func TestRepositoryUpdateItems(t *testing.T) {
 		t.Errorf("Could not update the repository")
 	}
 
-	items, _ := repo.GetIDsToPull(&[]int64{112})
+	items, _ := repo.GetIDsToPull(HackerNewsSourceType, &[]int64{112})
 
 	if len(items) != 1 {
 		t.Errorf("Expected 1 ID not in the repository, %d exist", len(items))
@@ -57,6 +63,46 @@ func TestRepositoryUpdateItems(t *testing.T) {
 	}
 }
 
+func TestRepositoryItemsOfOtherSources(t *testing.T) {
+	repo := DataRepository{dbConfig: Database{Driver: "sqlite3", Database: ":memory:"}}
+
+	if err := repo.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	defer repo.Close()
+
+	digest := &[]DigestItem{
+		{id: 111, newsTitle: "Some Item", newsUrl: "http://localhost", createdAt: 123456789, source: "blog"},
+	}
+
+	if err := repo.UpdateItems(digest); err != nil {
+		t.Fatal(err)
+	}
+
+	// An item of another source is pulled even with the same ID
+	if items, _ := repo.GetIDsToPull("news", &[]int64{111}); len(items) != 1 {
+		t.Errorf("Expected the item of another source to be pulled, got %v", items)
+	}
+
+	// But not if the other source shares the IDs
+	if items, _ := repo.GetIDsToPull("news", &[]int64{111}, "blog"); len(items) != 0 {
+		t.Errorf("Expected the item of a sharing source not to be pulled, got %v", items)
+	}
+
+	// Nor if the ID is made up from the item's own key, e.g. by a feed renamed since
+	feedID := namespacedID("http://some-host/feed.xml", "https://some-host/1")
+
+	if err := repo.UpdateItems(&[]DigestItem{{id: feedID, newsTitle: "Some Entry", newsUrl: "https://some-host/1",
+		createdAt: 123456789, source: "old-blog"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if items, _ := repo.GetIDsToPull("blog", &[]int64{feedID}); len(items) != 0 {
+		t.Errorf("Expected the item with a made up ID not to be pulled again, got %v", items)
+	}
+}
+
 func TestVacuumRepository(t *testing.T) {
 	repo := DataRepository{dbConfig: Database{Driver: "sqlite3", Database: ":memory:"}}
 
@@ -72,3 +118,452 @@ func TestVacuumRepository(t *testing.T) {
 		t.Errorf("Error while vacuuming the repository, %v", err)
 	}
 }
+
+func TestRepositoryIndexes(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "indexed.sqlite")
+
+	// Opening the database again does not index the columns twice
+	for range 2 {
+		repo := DataRepository{dbConfig: Database{Driver: "sqlite3", Database: dbFile}, purgeAfter: 100000}
+
+		if err := repo.Init(); err != nil {
+			t.Fatalf("Error while preparing a test database, %v", err)
+		}
+
+		var indexes []string
+		if err := repo.db.Select(&indexes, "SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ?",
+			TableName); err != nil {
+			t.Fatal(err)
+		}
+
+		repo.Close()
+
+		if len(indexes) != 1 || indexes[0] != TableName+"_canonical_url" {
+			t.Errorf("Expected the canonical URLs to be indexed, got %v", indexes)
+		}
+	}
+}
+
+func TestMigrateOldRepository(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "old.sqlite")
+
+	oldDB, err := sqlx.Open("sqlite3", dbFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The table layout before the item details were stored
+	oldDB.MustExec(`CREATE TABLE news_items (id INTEGER PRIMARY KEY, created_at INTEGER NOT NULL,
+		news_title TEXT NOT NULL, news_url TEXT NOT NULL)`)
+	oldDB.MustExec("INSERT INTO news_items VALUES (1, 123456789, 'Old Item', 'http://localhost')")
+	oldDB.Close()
+
+	repo := DataRepository{dbConfig: Database{Driver: "sqlite3", Database: dbFile}, purgeAfter: 100000}
+
+	if err := repo.Init(); err != nil {
+		t.Fatalf("Error while migrating an old repository, %v", err)
+	}
+
+	defer repo.Close()
+
+	digest := &[]DigestItem{
+		{id: 2, newsTitle: "New Item", newsUrl: "http://localhost", createdAt: 123456789,
+			score: 42, author: "pg", comments: 7, itemType: "story"},
+	}
+
+	if err := repo.UpdateItems(digest); err != nil {
+		t.Fatalf("Could not update the migrated repository, %v", err)
+	}
+
+	var (
+		score    int64
+		author   string
+		comments int64
+	)
+
+	row := repo.db.QueryRow("SELECT score, author, comments FROM news_items WHERE id = 2")
+	if err := row.Scan(&score, &author, &comments); err != nil {
+		t.Fatal(err)
+	}
+
+	if score != 42 || author != "pg" || comments != 7 {
+		t.Errorf("Unexpected item details: score %d, author %s, comments %d", score, author, comments)
+	}
+
+	row = repo.db.QueryRow("SELECT score, author FROM news_items WHERE id = 1")
+	if err := row.Scan(&score, &author); err != nil {
+		t.Fatalf("Old items should get the default values, %v", err)
+	}
+}
+
+func TestMigrateBaselineDeliveries(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "baseline.sqlite")
+
+	oldDB, err := sqlx.Open("sqlite3", dbFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first release's table, which did not record the delivered items
+	oldDB.MustExec(`CREATE TABLE news_items (id INTEGER PRIMARY KEY, created_at INTEGER NOT NULL,
+		news_title TEXT NOT NULL, news_url TEXT NOT NULL)`)
+	oldDB.MustExec("INSERT INTO news_items VALUES (1, ?, 'Sent Item', 'http://localhost/sent')", time.Now().Unix())
+	oldDB.Close()
+
+	repo := DataRepository{dbConfig: Database{Driver: "sqlite3", Database: dbFile}, purgeAfter: 100000}
+
+	if err := repo.Init(); err != nil {
+		t.Fatalf("Error while migrating a baseline repository, %v", err)
+	}
+
+	defer repo.Close()
+
+	items, err := repo.UndeliveredItems(time.Now().AddDate(0, 0, -7))
+	if err != nil {
+		t.Fatalf("Could not load the undelivered items, %v", err)
+	}
+
+	if len(items) != 0 {
+		t.Errorf("Expected the items stored by the first release to be taken as delivered, got %v", items)
+	}
+}
+
+func TestMigrateCanonicalURLs(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "uncanonical.sqlite")
+
+	oldDB, err := sqlx.Open("sqlite3", dbFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldDB.MustExec(`CREATE TABLE news_items (id INTEGER PRIMARY KEY, created_at INTEGER NOT NULL,
+		news_title TEXT NOT NULL, news_url TEXT NOT NULL)`)
+	oldDB.MustExec("INSERT INTO news_items VALUES (1, ?, 'Sent Item', 'https://www.example.com/a/?utm_source=hn')",
+		time.Now().Unix())
+	oldDB.Close()
+
+	repo := DataRepository{dbConfig: Database{Driver: "sqlite3", Database: dbFile}, purgeAfter: 100000}
+
+	if err := repo.Init(); err != nil {
+		t.Fatalf("Error while migrating an old repository, %v", err)
+	}
+
+	defer repo.Close()
+
+	delivered, err := repo.DeliveredSince("example.com/a", time.Now().AddDate(0, 0, -1))
+	if err != nil {
+		t.Fatalf("Could not look up the delivered link, %v", err)
+	}
+
+	if !delivered {
+		t.Errorf("Expected the old item to get its canonical URL and be told as delivered")
+	}
+}
+
+func TestIsMissingColumn(t *testing.T) {
+	repo := DataRepository{dbConfig: Database{Driver: "sqlite3", Database: ":memory:"}}
+
+	if err := repo.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	defer repo.Close()
+
+	_, err := repo.db.Exec("SELECT no_such_column FROM news_items")
+	if !isMissingColumn(err) {
+		t.Errorf("A missing column should be told, got %v", err)
+	}
+
+	// Any other failure must not be taken for a missing column, so that nothing is altered on it
+	_, err = repo.db.Exec("SELECT id FROM no_such_table")
+	if err == nil || isMissingColumn(err) {
+		t.Errorf("A missing table should not be taken for a missing column, got %v", err)
+	}
+
+	if isMissingColumn(errors.New("database is locked")) {
+		t.Error("A locked database should not be taken for a missing column")
+	}
+}
+
+func TestRepositoryPendingItems(t *testing.T) {
+	repo := DataRepository{dbConfig: Database{Driver: "sqlite3", Database: ":memory:"}}
+
+	if err := repo.Init(); err != nil {
+		t.Fatalf("Error while preparing a test database in memory, %v", err)
+	}
+
+	defer repo.Close()
+
+	pending := &[]DigestItem{{id: 111, newsTitle: "Pending", newsUrl: "http://localhost", createdAt: 123456789}}
+
+	for range 2 {
+		if err := repo.DeferItems(pending); err != nil {
+			t.Fatalf("Could not defer the items, %v", err)
+		}
+	}
+
+	var attempts, nextCheckAt int64
+
+	row := repo.db.QueryRow("SELECT attempts, next_check_at FROM news_items WHERE id = 111 AND status = 'pending'")
+	if err := row.Scan(&attempts, &nextCheckAt); err != nil {
+		t.Fatal(err)
+	}
+
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+
+	if nextCheckAt <= time.Now().Unix() {
+		t.Errorf("Expected the next check to be in the future")
+	}
+
+	// Not due yet, so neither the prefetched nor the pending ID is pulled
+	if items, _ := repo.GetIDsToPull(HackerNewsSourceType, &[]int64{111}); len(items) != 0 {
+		t.Errorf("Expected no IDs to pull, got %v", items)
+	}
+
+	repo.db.MustExec("UPDATE news_items SET next_check_at = 0")
+
+	// Due pending items are pulled even when they are not prefetched anymore
+	items, _ := repo.GetIDsToPull(HackerNewsSourceType, &[]int64{112})
+	if len(items) != 2 || items[0] != 112 || items[1] != 111 {
+		t.Errorf("Expected IDs [112 111] to pull, got %v", items)
+	}
+}
+
+func TestRepositoryBackoff(t *testing.T) {
+	repo := DataRepository{pending: PendingConfig{BackoffMinutes: 10, MaxBackoffMinutes: 60}}
+
+	expected := map[int64]time.Duration{
+		1: 10 * time.Minute,
+		2: 20 * time.Minute,
+		3: 40 * time.Minute,
+		4: 60 * time.Minute,
+		9: 60 * time.Minute,
+	}
+
+	for attempts, delay := range expected {
+		if repo.backoff(attempts) != delay {
+			t.Errorf("Expected a delay of %v after %d attempts, got %v", delay, attempts, repo.backoff(attempts))
+		}
+	}
+}
+
+func TestRepositoryFailedItems(t *testing.T) {
+	repo := DataRepository{dbConfig: Database{Driver: "sqlite3", Database: ":memory:"}, maxRetries: 2}
+
+	if err := repo.Init(); err != nil {
+		t.Fatalf("Error while preparing a test database in memory, %v", err)
+	}
+
+	defer repo.Close()
+
+	failures := &[]fetchFailure{{id: 111, err: fmt.Errorf("connection reset"), source: HackerNewsSourceType}}
+
+	if err := repo.RecordFailures(failures); err != nil {
+		t.Fatalf("Could not record the failed items, %v", err)
+	}
+
+	// Failed items are retried even when they are not prefetched anymore
+	items, _ := repo.GetIDsToPull(HackerNewsSourceType, &[]int64{112})
+	if len(items) != 2 || items[0] != 112 || items[1] != 111 {
+		t.Errorf("Expected IDs [112 111] to pull, got %v", items)
+	}
+
+	// Other sources' failures are not pulled
+	if items, _ := repo.GetIDsToPull("lobsters", &[]int64{}); len(items) != 0 {
+		t.Errorf("Expected no IDs to pull, got %v", items)
+	}
+
+	if err := repo.RecordFailures(failures); err != nil {
+		t.Fatalf("Could not record the failed items, %v", err)
+	}
+
+	var lastError string
+	if err := repo.db.Get(&lastError,
+		"SELECT last_error FROM fetch_retries WHERE id = 111 AND attempts = 2"); err != nil {
+		t.Fatal(err)
+	}
+
+	if lastError != "connection reset" {
+		t.Errorf("Expected the last error to be recorded, got %q", lastError)
+	}
+
+	// Given up after the maximum attempts, even when prefetched again
+	if items, _ := repo.GetIDsToPull(HackerNewsSourceType, &[]int64{111}); len(items) != 0 {
+		t.Errorf("Expected no IDs to pull, got %v", items)
+	}
+
+	if err := repo.ClearFailures([]int64{111}); err != nil {
+		t.Fatalf("Could not clear the failed items, %v", err)
+	}
+
+	if items, _ := repo.GetIDsToPull(HackerNewsSourceType, &[]int64{111}); len(items) != 1 {
+		t.Errorf("Expected the cleared ID to be pulled, got %v", items)
+	}
+}
+
+func TestRepositoryFailedPendingItems(t *testing.T) {
+	repo := DataRepository{dbConfig: Database{Driver: "sqlite3", Database: ":memory:"}, maxRetries: 2}
+
+	if err := repo.Init(); err != nil {
+		t.Fatalf("Error while preparing a test database in memory, %v", err)
+	}
+
+	defer repo.Close()
+
+	err := repo.DeferItems(&[]DigestItem{{id: 111, newsTitle: "Some Item", newsUrl: "http://localhost",
+		createdAt: time.Now().Unix()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo.db.MustExec("UPDATE news_items SET next_check_at = 0")
+
+	failures := &[]fetchFailure{{id: 111, err: fmt.Errorf("connection reset"), source: HackerNewsSourceType}}
+
+	for range 2 {
+		if items, _ := repo.GetIDsToPull(HackerNewsSourceType, &[]int64{}); len(items) != 1 {
+			t.Fatalf("Expected the due pending item to be pulled, got %v", items)
+		}
+
+		if err := repo.RecordFailures(failures); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A pending item that keeps failing is given up like any other
+	if items, _ := repo.GetIDsToPull(HackerNewsSourceType, &[]int64{}); len(items) != 0 {
+		t.Errorf("Expected the failing pending item to be given up, got %v", items)
+	}
+
+	var status string
+	if err := repo.db.Get(&status, "SELECT status FROM news_items WHERE id = 111"); err != nil {
+		t.Fatal(err)
+	}
+
+	if status != StatusExpired {
+		t.Errorf("Expected the given up pending item to expire, got %s", status)
+	}
+
+	// Pending items given up before they expired on a failure are not pulled either
+	repo.db.MustExec("UPDATE news_items SET status = ?", StatusPending)
+
+	if items, _ := repo.GetIDsToPull(HackerNewsSourceType, &[]int64{}); len(items) != 0 {
+		t.Errorf("Expected the given up pending item not to be pulled, got %v", items)
+	}
+}
+
+func TestRepositorySetStatus(t *testing.T) {
+	repo := DataRepository{dbConfig: Database{Driver: "sqlite3", Database: ":memory:"}}
+
+	if err := repo.Init(); err != nil {
+		t.Fatalf("Error while preparing a test database in memory, %v", err)
+	}
+
+	defer repo.Close()
+
+	err := repo.UpdateItems(&[]DigestItem{
+		{id: 1, newsTitle: "First", newsUrl: "http://localhost/1", status: StatusDelivered},
+		{id: 2, newsTitle: "Second", newsUrl: "http://localhost/2", status: StatusDelivered},
+	})
+	if err != nil {
+		t.Fatalf("Could not update the repository, %v", err)
+	}
+
+	if err := repo.SetStatus([]int64{2}, StatusSeen); err != nil {
+		t.Fatalf("Could not set the status, %v", err)
+	}
+
+	var statuses []string
+	if err := repo.db.Select(&statuses, "SELECT status FROM news_items ORDER BY id"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(statuses) != 2 || statuses[0] != StatusDelivered || statuses[1] != StatusSeen {
+		t.Errorf("Expected [delivered seen], got %v", statuses)
+	}
+
+	var deliveredAt []int64
+	if err := repo.db.Select(&deliveredAt, "SELECT delivered_at FROM news_items ORDER BY id"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deliveredAt) != 2 || deliveredAt[0] == 0 || deliveredAt[1] != 0 {
+		t.Errorf("Expected only the delivered item to have a delivery time, got %v", deliveredAt)
+	}
+}
+
+func TestRepositoryUndeliveredItems(t *testing.T) {
+	repo := DataRepository{dbConfig: Database{Driver: "sqlite3", Database: ":memory:"}}
+
+	if err := repo.Init(); err != nil {
+		t.Fatalf("Error while preparing a test database in memory, %v", err)
+	}
+
+	defer repo.Close()
+
+	now := time.Now()
+
+	err := repo.UpdateItems(&[]DigestItem{
+		{id: 1, newsTitle: "Seen", newsUrl: "http://host/1", createdAt: now.Unix()},
+		{id: 2, newsTitle: "Delivered", newsUrl: "http://host/2", createdAt: now.Unix(), status: StatusDelivered},
+		{id: 3, newsTitle: "Expired", newsUrl: "http://host/3", createdAt: now.Unix(), status: StatusExpired},
+		{id: 4, newsTitle: "Too old", newsUrl: "http://host/4", createdAt: now.Add(-48 * time.Hour).Unix()},
+	})
+	if err != nil {
+		t.Fatalf("Could not update the repository, %v", err)
+	}
+
+	err = repo.DeferItems(&[]DigestItem{{id: 5, newsTitle: "Pending", newsUrl: "http://host/5", createdAt: now.Unix()}})
+	if err != nil {
+		t.Fatalf("Could not defer the item, %v", err)
+	}
+
+	items, err := repo.UndeliveredItems(now.Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatalf("Could not load the undelivered items, %v", err)
+	}
+
+	if len(items) != 2 || items[0].Id != 1 || items[1].Id != 3 {
+		t.Errorf("Expected the seen and the expired items of the period, got %v", items)
+	}
+}
+
+func TestRepositoryStoredItems(t *testing.T) {
+	repo := DataRepository{dbConfig: Database{Driver: "sqlite3", Database: ":memory:"}}
+
+	if err := repo.Init(); err != nil {
+		t.Fatalf("Error while preparing a test database in memory, %v", err)
+	}
+
+	defer repo.Close()
+
+	now := time.Now().Unix()
+	digest := &[]DigestItem{
+		{id: 2, newsTitle: "Second", newsUrl: "http://host/2", createdAt: now, author: "pg", score: 10,
+			comments: 5, newsText: "Some text", discussionUrl: "http://host/item?id=2", status: StatusDelivered},
+		{id: 1, newsTitle: "First", newsUrl: "http://host/1", createdAt: now - 60},
+		{id: 3, newsTitle: "-", newsUrl: "-", createdAt: now},
+	}
+
+	if err := repo.UpdateItems(digest); err != nil {
+		t.Fatalf("Could not update the repository, %v", err)
+	}
+
+	items, err := repo.StoredItems()
+	if err != nil {
+		t.Fatalf("Could not load the stored items, %v", err)
+	}
+
+	if len(items) != 2 || items[0].Id != 1 || items[1].Id != 2 {
+		t.Fatalf("Expected the titled items, the oldest first, got %v", items)
+	}
+
+	expected := JsonNewsItem{Id: 2, Title: "Second", Url: "http://host/2", Time: now, By: "pg", Score: 10,
+		Descendants: 5, Text: "Some text", Source: HackerNewsSourceType, Discussion: "http://host/item?id=2"}
+
+	if fmt.Sprint(items[1]) != fmt.Sprint(expected) {
+		t.Errorf("Expected the item to be restored as %v, got %v", expected, items[1])
+	}
+}
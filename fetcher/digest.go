@@ -0,0 +1,60 @@
+package fetcher
+
+import "golang.org/x/exp/slices"
+
+// Heading of the items that matched none of the ordered filters, e.g. in the reverse mode
+const OtherGroupTitle = "Other"
+
+// DigestGroup Digest items listed under the title of a filter they matched
+type DigestGroup struct {
+	Title string
+	Items []DigestItem
+}
+
+// Group the digest items under the first filter title they matched, in the provided order
+// of titles, with the rest in the "Other" group. A digest none of whose items matched a filter
+// is a single group without a title, so that it is rendered as a flat list.
+func groupDigest(digest []DigestItem, order []string) []DigestGroup {
+	groups := make([]DigestGroup, len(order)+1)
+
+	for idx, title := range order {
+		groups[idx].Title = title
+	}
+
+	groups[len(order)].Title = OtherGroupTitle
+
+	for _, item := range digest {
+		groupIdx := len(order)
+
+		for idx, title := range order {
+			if slices.Contains(item.filters, title) {
+				groupIdx = idx
+				break
+			}
+		}
+
+		groups[groupIdx].Items = append(groups[groupIdx].Items, item)
+	}
+
+	if len(groups[len(order)].Items) == len(digest) {
+		return []DigestGroup{{Items: digest}}
+	}
+
+	return slices.DeleteFunc(groups, func(group DigestGroup) bool {
+		return len(group.Items) == 0
+	})
+}
+
+// Order of the digest groups: the configured titles first, then the other filters' titles
+// in the order they are configured
+func groupOrder(settings *Configuration) []string {
+	order := slices.Clone(settings.Digest.GroupOrder)
+
+	for _, filter := range configuredFilters(settings) {
+		if !slices.Contains(order, filter.Title) {
+			order = append(order, filter.Title)
+		}
+	}
+
+	return order
+}
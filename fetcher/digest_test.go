@@ -0,0 +1,62 @@
+package fetcher
+
+import (
+	"testing"
+
+	"golang.org/x/exp/slices"
+)
+
+func TestGroupDigest(t *testing.T) {
+	digest := []DigestItem{
+		{id: 1, filters: []string{"Hackers"}},
+		{id: 2},
+		{id: 3, filters: []string{"Hackers", "Linux"}},
+		{id: 4, filters: []string{"Linux"}},
+	}
+
+	groups := groupDigest(digest, []string{"Linux", "Hackers", "Rust"})
+
+	if len(groups) != 3 {
+		t.Fatalf("Expected 3 groups, got %v", groups)
+	}
+
+	expected := []struct {
+		title string
+		ids   []int64
+	}{
+		{title: "Linux", ids: []int64{3, 4}},
+		{title: "Hackers", ids: []int64{1}},
+		{title: OtherGroupTitle, ids: []int64{2}},
+	}
+
+	for idx, group := range groups {
+		var ids []int64
+		for _, item := range group.Items {
+			ids = append(ids, item.id)
+		}
+
+		if group.Title != expected[idx].title || !slices.Equal(ids, expected[idx].ids) {
+			t.Errorf("Expected group %s with %v, got %s with %v", expected[idx].title, expected[idx].ids,
+				group.Title, ids)
+		}
+	}
+}
+
+func TestGroupDigestWithoutFilters(t *testing.T) {
+	groups := groupDigest([]DigestItem{{id: 1}, {id: 2}}, []string{"Linux"})
+
+	if len(groups) != 1 || groups[0].Title != "" || len(groups[0].Items) != 2 {
+		t.Errorf("Expected a single group without a title, got %v", groups)
+	}
+}
+
+func TestGroupOrder(t *testing.T) {
+	settings := Configuration{
+		Filters: []FilterItem{{Title: "SQL"}, {Title: "Linux"}, {Title: "Hackers"}},
+		Digest:  DigestConfig{GroupOrder: []string{"Hackers"}},
+	}
+
+	if order := groupOrder(&settings); !slices.Equal(order, []string{"Hackers", "SQL", "Linux"}) {
+		t.Errorf("Expected the configured groups first, got %v", order)
+	}
+}
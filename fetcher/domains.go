@@ -0,0 +1,104 @@
+package fetcher
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// A compiled entry of the blacklisted or whitelisted domains
+type domainPattern struct {
+	value string
+	// Labels matched against the end of a host's labels; "*" matches any one label
+	labels []string
+	// Set for the patterns ending with ".*", which match the domain under any public suffix
+	anySuffix bool
+}
+
+// Lower-cased host of a URL, without the port and the trailing dot
+func urlHost(rawURL string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSuffix(strings.ToLower(parsedURL.Hostname()), ".")
+}
+
+// Compile a domains list. A domain matches its subdomains too, and a leading "www." is dropped,
+// so "www.businessinsider.com" matches "markets.businessinsider.com". "*" matches any one label,
+// e.g. "*.medium.com" matches the subdomains only, and a trailing ".*" matches any public suffix,
+// e.g. "businessinsider.*" matches "businessinsider.de". A bare public suffix, like "com" or
+// "co.uk", would match too many sites to be meant, so it is an error.
+func compileDomains(domains []string) ([]domainPattern, error) {
+	patterns := make([]domainPattern, 0, len(domains))
+
+	for _, domain := range domains {
+		value := strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+		pattern := domainPattern{value: domain}
+
+		if rest, found := strings.CutSuffix(value, ".*"); found {
+			pattern.anySuffix = true
+			value = rest
+		}
+
+		if value == "" || value == "*" {
+			return nil, fmt.Errorf("domain %q matches every site", domain)
+		}
+
+		if suffix, icann := publicsuffix.PublicSuffix(value); icann && suffix == value && !pattern.anySuffix {
+			return nil, fmt.Errorf("domain %q is a public suffix", domain)
+		}
+
+		if rest, found := strings.CutPrefix(value, "www."); found {
+			if suffix, _ := publicsuffix.PublicSuffix(rest); suffix != rest {
+				value = rest
+			}
+		}
+
+		pattern.labels = strings.Split(value, ".")
+		patterns = append(patterns, pattern)
+	}
+
+	return patterns, nil
+}
+
+// Whether the host is the pattern's domain or its subdomain
+func (pattern *domainPattern) matches(host string) bool {
+	if host == "" {
+		return false
+	}
+
+	if pattern.anySuffix {
+		suffix, _ := publicsuffix.PublicSuffix(host)
+		host = strings.TrimSuffix(strings.TrimSuffix(host, suffix), ".")
+	}
+
+	labels := strings.Split(host, ".")
+	offset := len(labels) - len(pattern.labels)
+
+	if offset < 0 {
+		return false
+	}
+
+	for idx, label := range pattern.labels {
+		if label != "*" && label != labels[offset+idx] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// The first of the domain patterns the host matches, if any
+func matchDomain(patterns []domainPattern, host string) (string, bool) {
+	for idx := range patterns {
+		if patterns[idx].matches(host) {
+			return patterns[idx].value, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,46 @@
+package fetcher
+
+import (
+	"testing"
+)
+
+func TestDomainPatterns(t *testing.T) {
+	patterns, err := compileDomains([]string{
+		"www.businessinsider.com", "*.medium.com", "example.*", "github.io", "localhost",
+	})
+	if err != nil {
+		t.Fatalf("Could not compile the domains, %v", err)
+	}
+
+	testCases := []struct {
+		url      string
+		expected string
+	}{
+		{url: "https://www.businessinsider.com/some-story", expected: "www.businessinsider.com"},
+		{url: "https://markets.businessinsider.com:443/some-story", expected: "www.businessinsider.com"},
+		{url: "https://BusinessInsider.com./some-story", expected: "www.businessinsider.com"},
+		{url: "https://notbusinessinsider.com/", expected: ""},
+		{url: "https://engineering.medium.com/post", expected: "*.medium.com"},
+		{url: "https://medium.com/post", expected: ""},
+		{url: "https://example.co.uk/", expected: "example.*"},
+		{url: "https://blog.example.de/", expected: "example.*"},
+		{url: "https://example.org.evil.com/", expected: ""},
+		{url: "https://someone.github.io/", expected: "github.io"},
+		{url: "http://localhost:8080/", expected: "localhost"},
+		{url: "-", expected: ""},
+	}
+
+	for _, testCase := range testCases {
+		if matched, _ := matchDomain(patterns, urlHost(testCase.url)); matched != testCase.expected {
+			t.Errorf("Expected %s to match %q, got %q", testCase.url, testCase.expected, matched)
+		}
+	}
+}
+
+func TestInvalidDomainPatterns(t *testing.T) {
+	for _, domain := range []string{"com", "co.uk", "*", ".", ""} {
+		if _, err := compileDomains([]string{domain}); err == nil {
+			t.Errorf("Expected %q to be rejected", domain)
+		}
+	}
+}
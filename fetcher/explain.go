@@ -0,0 +1,126 @@
+package fetcher
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Parse a test-filter input: a title, a URL, or a title followed by a URL
+func parseTestInput(input string) JsonNewsItem {
+	input = strings.TrimSpace(input)
+	item := JsonNewsItem{Title: input}
+
+	words := strings.Fields(input)
+	if len(words) == 0 {
+		return item
+	}
+
+	link := words[len(words)-1]
+	if parsedURL, err := url.Parse(link); err != nil || parsedURL.Host == "" ||
+		(parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+		return item
+	}
+
+	item.Url = link
+	item.Title = strings.TrimSpace(strings.TrimSuffix(input, link))
+
+	return item
+}
+
+// ReadTestInputs Read the test-filter inputs from a file, one per line, skipping the empty lines
+func ReadTestInputs(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	var inputs []string
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			inputs = append(inputs, line)
+		}
+	}
+
+	return inputs, scanner.Err()
+}
+
+// The final decision on a news item in the normal or the reverse mode, with its reason.
+// The thresholds are not checked, as the test inputs have no points, comments or age.
+func (f *Fetcher) decide(newItem *JsonNewsItem, reverse bool) string {
+	var hits, vetoed []string
+
+	for _, match := range f.explainFilters(newItem) {
+		if match.Hit() {
+			hits = append(hits, match.Title)
+		} else {
+			vetoed = append(vetoed, match.Title)
+		}
+	}
+
+	// Without filter hits, an item gets in only in the reverse mode
+	included, reason := reverse, "no filter matched"
+
+	switch {
+	case !reverse && f.isWhitelisted(newItem):
+		return "included, the domain is whitelisted"
+	case !f.filterBlacklisted(newItem):
+		return "excluded, the domain is blacklisted"
+	case len(hits) > 0:
+		included, reason = !reverse, "matched "+strings.Join(hits, ", ")
+	case len(vetoed) > 0:
+		reason = "the matches of " + strings.Join(vetoed, ", ") + " were excluded"
+	}
+
+	if included {
+		return "included, " + reason
+	}
+
+	return "excluded, " + reason
+}
+
+// TestFilters Explain which filters and patterns hit the provided titles and URLs, the blacklist
+// and whitelist decisions, and whether the items get into the digest in the normal and reverse modes
+func (f *Fetcher) TestFilters(inputs []string, out io.Writer) error {
+	if err := f.prepareFilters(); err != nil {
+		return err
+	}
+
+	for _, input := range inputs {
+		newItem := parseTestInput(input)
+
+		fmt.Fprintf(out, "Title: %q\n", newItem.Title)
+		if newItem.Url != "" {
+			fmt.Fprintf(out, "URL: %s\n", newItem.Url)
+		}
+
+		matches := f.explainFilters(&newItem)
+		if len(matches) == 0 {
+			fmt.Fprintln(out, "  No filter matched")
+		}
+
+		for _, match := range matches {
+			fmt.Fprintf(out, "  Filter %s\n", match.String())
+		}
+
+		if domain, blacklisted := matchDomain(f.blacklist, urlHost(newItem.Url)); blacklisted {
+			fmt.Fprintf(out, "  Blacklisted by %q\n", domain)
+		}
+
+		if domain, whitelisted := matchDomain(f.whitelist, urlHost(newItem.Url)); whitelisted {
+			fmt.Fprintf(out, "  Whitelisted by %q\n", domain)
+		}
+
+		fmt.Fprintf(out, "  Normal mode: %s\n", f.decide(&newItem, false))
+		fmt.Fprintf(out, "  Reverse mode: %s\n\n", f.decide(&newItem, true))
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package fetcher
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseTestInput(t *testing.T) {
+	testCases := []struct {
+		input, title, url string
+	}{
+		{input: "Linux 7.0 released", title: "Linux 7.0 released"},
+		{input: "https://arxiv.org/abs/1", url: "https://arxiv.org/abs/1"},
+		{input: " Linux 7.0 released https://kernel.org/ ", title: "Linux 7.0 released", url: "https://kernel.org/"},
+		{input: "Ask HN: ftp://host or http:// links?", title: "Ask HN: ftp://host or http:// links?"},
+	}
+
+	for _, testCase := range testCases {
+		item := parseTestInput(testCase.input)
+
+		if item.Title != testCase.title || item.Url != testCase.url {
+			t.Errorf("Expected %q to be %q and %q, got %q and %q", testCase.input, testCase.title, testCase.url,
+				item.Title, item.Url)
+		}
+	}
+}
+
+func TestTestFilters(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		Filters: []FilterItem{
+			{Title: "API", Value: `api\b`, Exclude: []string{"rapid"}},
+			{Title: "Linux", Value: "linux"},
+		},
+		BlacklistedDomains: []string{"businessinsider.com"},
+		WhitelistedDomains: []string{"lwn.net"},
+	}}
+
+	var out bytes.Buffer
+
+	err := fetcher.TestFilters([]string{
+		"Rapid API prototyping",
+		"Linux on the desktop https://markets.businessinsider.com/1",
+		"Kernel news https://lwn.net/Articles/1/",
+		"Linux on the desktop",
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`Filter API: pattern "api\b" matched, excluded by exclude pattern "rapid"`,
+		"Normal mode: excluded, the matches of API were excluded",
+		"Reverse mode: included, the matches of API were excluded",
+		`Blacklisted by "businessinsider.com"`,
+		"Normal mode: excluded, the domain is blacklisted",
+		`Whitelisted by "lwn.net"`,
+		"Normal mode: included, the domain is whitelisted",
+		"  No filter matched",
+		`Filter Linux: pattern "linux" matched`,
+		"Normal mode: included, matched Linux",
+		"Reverse mode: excluded, matched Linux",
+	}
+
+	for _, line := range expected {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("Expected %q in the output:\n%s", line, out.String())
+		}
+	}
+
+	// The reverse mode skips the whitelist
+	if strings.Contains(out.String(), "Reverse mode: included, the domain is whitelisted") {
+		t.Errorf("Expected the whitelist to be skipped in the reverse mode:\n%s", out.String())
+	}
+}
+
+func TestReadTestInputs(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "titles.txt")
+
+	if err := os.WriteFile(filename, []byte("First title\n\n  https://host/2  \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	inputs, err := ReadTestInputs(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(inputs) != 2 || inputs[0] != "First title" || inputs[1] != "https://host/2" {
+		t.Errorf("Expected 2 inputs, got %q", inputs)
+	}
+}
@@ -0,0 +1,450 @@
+package fetcher
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/exp/slices"
+)
+
+// Fields of a news item a filter expression can match text against
+var TextFields = []string{"title", "url", "domain", "by", "text"}
+
+// Fields of a news item a filter expression can compare numbers with
+var NumericFields = []string{"score", "comments"}
+
+// Text a news item has in one of the text fields
+func itemField(item *JsonNewsItem, field string) string {
+	switch field {
+	case "url":
+		return item.Url
+	case "domain":
+		return itemDomain(item)
+	case "by":
+		return item.By
+	case "text":
+		return item.Text
+	default:
+		return item.Title
+	}
+}
+
+// Lower-cased host of a news item's URL, without the port
+func itemDomain(item *JsonNewsItem) string {
+	return urlHost(item.Url)
+}
+
+// ExpressionError A filter expression that could not be parsed
+type ExpressionError struct {
+	Message  string
+	Position int
+}
+
+func (e *ExpressionError) Error() string {
+	return fmt.Sprintf("at position %d: %s", e.Position, e.Message)
+}
+
+// A parsed filter expression
+type filterExpression interface {
+	eval(item *JsonNewsItem) bool
+}
+
+type andExpression struct {
+	left, right filterExpression
+}
+
+func (e *andExpression) eval(item *JsonNewsItem) bool {
+	return e.left.eval(item) && e.right.eval(item)
+}
+
+type orExpression struct {
+	left, right filterExpression
+}
+
+func (e *orExpression) eval(item *JsonNewsItem) bool {
+	return e.left.eval(item) || e.right.eval(item)
+}
+
+type notExpression struct {
+	operand filterExpression
+}
+
+func (e *notExpression) eval(item *JsonNewsItem) bool {
+	return !e.operand.eval(item)
+}
+
+// A word, a quoted phrase or a regex literal matched against a text field
+type textExpression struct {
+	field string
+	regex *regexp.Regexp
+}
+
+func (e *textExpression) eval(item *JsonNewsItem) bool {
+	return e.regex.MatchString(itemField(item, e.field))
+}
+
+// A comparison of a numeric field with a number, e.g. score>100
+type compareExpression struct {
+	field    string
+	operator string
+	value    int64
+}
+
+func (e *compareExpression) eval(item *JsonNewsItem) bool {
+	actual := item.Score
+	if e.field == "comments" {
+		actual = item.Descendants
+	}
+
+	switch e.operator {
+	case ">":
+		return actual > e.value
+	case ">=":
+		return actual >= e.value
+	case "<":
+		return actual < e.value
+	case "<=":
+		return actual <= e.value
+	default:
+		return actual == e.value
+	}
+}
+
+// Tokens of the filter expressions
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenLeftParen
+	tokenRightParen
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenField
+	tokenWord
+	tokenPhrase
+	tokenRegex
+	tokenCompare
+)
+
+type token struct {
+	value    string
+	operator string
+	number   string
+	kind     tokenKind
+	position int
+}
+
+var compareToken = regexp.MustCompile(`^([a-z]+)(>=|<=|>|<|=)(.*)$`)
+
+// Split an expression into tokens. Positions are 1-based, to be reported in the errors.
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+
+	runes := []rune(expression)
+
+	for pos := 0; pos < len(runes); {
+		char := runes[pos]
+
+		switch {
+		case unicode.IsSpace(char):
+			pos++
+		case char == '(':
+			tokens = append(tokens, token{kind: tokenLeftParen, position: pos + 1})
+			pos++
+		case char == ')':
+			tokens = append(tokens, token{kind: tokenRightParen, position: pos + 1})
+			pos++
+		case char == '"' || char == '/':
+			value, end, err := readQuoted(runes, pos)
+			if err != nil {
+				return nil, err
+			}
+
+			kind := tokenPhrase
+			if char == '/' {
+				kind = tokenRegex
+			}
+
+			tokens = append(tokens, token{kind: kind, value: value, position: pos + 1})
+			pos = end
+		default:
+			start := pos
+			for pos < len(runes) && !unicode.IsSpace(runes[pos]) && !strings.ContainsRune(`()"`, runes[pos]) {
+				// A field prefix ends the word, so that a phrase or a regex can follow it
+				if runes[pos] == ':' {
+					break
+				}
+
+				pos++
+			}
+
+			word := string(runes[start:pos])
+
+			if pos < len(runes) && runes[pos] == ':' {
+				if !slices.Contains(TextFields, word) {
+					return nil, &ExpressionError{Position: start + 1, Message: fmt.Sprintf("unknown field %q", word)}
+				}
+
+				tokens = append(tokens, token{kind: tokenField, value: word, position: start + 1})
+				pos++
+
+				continue
+			}
+
+			tokens = append(tokens, wordToken(word, start+1))
+		}
+	}
+
+	return append(tokens, token{kind: tokenEnd, position: len(runes) + 1}), nil
+}
+
+// Read a phrase in double quotes or a regex in slashes; a backslash escapes the closing character
+func readQuoted(runes []rune, start int) (string, int, error) {
+	var value strings.Builder
+
+	closing := runes[start]
+
+	for pos := start + 1; pos < len(runes); pos++ {
+		switch {
+		case runes[pos] == '\\' && pos+1 < len(runes) && runes[pos+1] == closing:
+			value.WriteRune(closing)
+			pos++
+		case runes[pos] == closing:
+			return value.String(), pos + 1, nil
+		default:
+			value.WriteRune(runes[pos])
+		}
+	}
+
+	return "", 0, &ExpressionError{Position: start + 1, Message: fmt.Sprintf("unterminated %c", closing)}
+}
+
+// Operators, comparisons and plain words
+func wordToken(word string, position int) token {
+	switch word {
+	case "AND":
+		return token{kind: tokenAnd, position: position}
+	case "OR":
+		return token{kind: tokenOr, position: position}
+	case "NOT":
+		return token{kind: tokenNot, position: position}
+	}
+
+	if parts := compareToken.FindStringSubmatch(word); parts != nil {
+		return token{kind: tokenCompare, value: parts[1], operator: parts[2], number: parts[3], position: position}
+	}
+
+	return token{kind: tokenWord, value: word, position: position}
+}
+
+// Recursive descent parser of the filter expressions:
+//
+//	or      = and { "OR" and }
+//	and     = not { ["AND"] not }
+//	not     = "NOT" not | primary
+//	primary = "(" or ")" | [field ":"] (word | phrase | regex) | comparison
+type expressionParser struct {
+	tokens []token
+	pos    int
+}
+
+// Parse a filter expression, e.g. `rust AND (async OR tokio) NOT title:job score>100`
+func parseExpression(expression string) (filterExpression, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := expressionParser{tokens: tokens}
+
+	if parser.peek().kind == tokenEnd {
+		return nil, &ExpressionError{Position: 1, Message: "empty expression"}
+	}
+
+	result, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if next := parser.peek(); next.kind != tokenEnd {
+		return nil, &ExpressionError{Position: next.position, Message: "unexpected " + parser.describe(next)}
+	}
+
+	return result, nil
+}
+
+func (p *expressionParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *expressionParser) next() token {
+	current := p.tokens[p.pos]
+
+	if current.kind != tokenEnd {
+		p.pos++
+	}
+
+	return current
+}
+
+func (p *expressionParser) parseOr() (filterExpression, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenOr {
+		p.next()
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &orExpression{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *expressionParser) parseAnd() (filterExpression, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch p.peek().kind {
+		case tokenAnd:
+			p.next()
+		case tokenEnd, tokenOr, tokenRightParen:
+			return left, nil
+		}
+
+		// Terms next to each other are AND'ed, e.g. `rust NOT job`
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &andExpression{left: left, right: right}
+	}
+}
+
+func (p *expressionParser) parseNot() (filterExpression, error) {
+	if p.peek().kind == tokenNot {
+		p.next()
+
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		return &notExpression{operand: operand}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *expressionParser) parsePrimary() (filterExpression, error) {
+	current := p.next()
+
+	switch current.kind {
+	case tokenLeftParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if closing := p.next(); closing.kind != tokenRightParen {
+			return nil, &ExpressionError{Position: closing.position,
+				Message: fmt.Sprintf("expected \")\" to close the \"(\" at position %d, got %s",
+					current.position, p.describe(closing))}
+		}
+
+		return inner, nil
+	case tokenField:
+		operand := p.next()
+		if operand.kind != tokenWord && operand.kind != tokenPhrase && operand.kind != tokenRegex {
+			return nil, &ExpressionError{Position: operand.position,
+				Message: fmt.Sprintf("expected a word, a phrase or a regex after %q, got %s", current.value+":",
+					p.describe(operand))}
+		}
+
+		return p.textTerm(current.value, operand)
+	case tokenWord, tokenPhrase, tokenRegex:
+		return p.textTerm("title", current)
+	case tokenCompare:
+		return p.compareTerm(current)
+	default:
+		return nil, &ExpressionError{Position: current.position,
+			Message: "expected a word, a phrase, a regex or \"(\", got " + p.describe(current)}
+	}
+}
+
+// A word or a phrase matches whole words, case-insensitively; a regex is used as it is
+func (p *expressionParser) textTerm(field string, operand token) (filterExpression, error) {
+	pattern := operand.value
+
+	if operand.kind != tokenRegex {
+		words := strings.Fields(operand.value)
+		if len(words) == 0 {
+			return nil, &ExpressionError{Position: operand.position, Message: "empty phrase"}
+		}
+
+		for idx, word := range words {
+			words[idx] = regexp.QuoteMeta(word)
+		}
+
+		pattern = `(^|\W)` + strings.Join(words, `\s+`) + `(\W|$)`
+	}
+
+	regex, err := regexp.Compile(RegexCaseInsensitive + pattern)
+	if err != nil {
+		return nil, &ExpressionError{Position: operand.position, Message: err.Error()}
+	}
+
+	return &textExpression{field: field, regex: regex}, nil
+}
+
+func (p *expressionParser) compareTerm(operand token) (filterExpression, error) {
+	if !slices.Contains(NumericFields, operand.value) {
+		return nil, &ExpressionError{Position: operand.position,
+			Message: fmt.Sprintf("unknown numeric field %q", operand.value)}
+	}
+
+	value, err := strconv.ParseInt(operand.number, 10, 64)
+	if err != nil {
+		return nil, &ExpressionError{Position: operand.position,
+			Message: fmt.Sprintf("%q is not a number", operand.number)}
+	}
+
+	return &compareExpression{field: operand.value, operator: operand.operator, value: value}, nil
+}
+
+// A token as it is shown in the syntax errors
+func (p *expressionParser) describe(current token) string {
+	switch current.kind {
+	case tokenEnd:
+		return "the end of the expression"
+	case tokenLeftParen:
+		return `"("`
+	case tokenRightParen:
+		return `")"`
+	case tokenAnd:
+		return `"AND"`
+	case tokenOr:
+		return `"OR"`
+	case tokenNot:
+		return `"NOT"`
+	case tokenCompare:
+		return fmt.Sprintf("%q", current.value+current.operator+current.number)
+	default:
+		return fmt.Sprintf("%q", current.value)
+	}
+}
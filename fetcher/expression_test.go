@@ -0,0 +1,115 @@
+package fetcher
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseExpression(t *testing.T) {
+	item := JsonNewsItem{
+		Title:       "Async Rust with Tokio, part 2",
+		Url:         "https://github.com:443/tokio-rs/tokio",
+		By:          "carllerche",
+		Text:        "A deep dive into the runtime",
+		Score:       150,
+		Descendants: 42,
+	}
+
+	testCases := []struct {
+		expression string
+		expected   bool
+	}{
+		{expression: "rust", expected: Hit},
+		{expression: "RUST", expected: Hit},
+		{expression: "rus", expected: Miss},
+		{expression: "rust AND (async OR tokio) NOT job", expected: Hit},
+		{expression: "rust (async OR tokio) NOT part", expected: Miss},
+		{expression: "go OR python OR tokio", expected: Hit},
+		{expression: "NOT NOT rust", expected: Hit},
+		{expression: `"rust with tokio"`, expected: Hit},
+		{expression: `"tokio with rust"`, expected: Miss},
+		{expression: `/part \d+$/`, expected: Hit},
+		{expression: `url:/tokio-rs\/tokio$/`, expected: Hit},
+		{expression: "domain:github.com", expected: Hit},
+		{expression: "domain:gitlab.com", expected: Miss},
+		{expression: "by:carllerche", expected: Hit},
+		{expression: `text:"deep dive"`, expected: Hit},
+		{expression: "title:runtime", expected: Miss},
+		{expression: "rust score>100", expected: Hit},
+		{expression: "rust score>=151", expected: Miss},
+		{expression: "comments<50 comments=42", expected: Hit},
+	}
+
+	for _, testCase := range testCases {
+		expression, err := parseExpression(testCase.expression)
+		if err != nil {
+			t.Errorf("Could not parse %q, %v", testCase.expression, err)
+			continue
+		}
+
+		if result := expression.eval(&item); result != testCase.expected {
+			t.Errorf("Expected %q to be %v, got %v", testCase.expression, testCase.expected, result)
+		}
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	testCases := []struct {
+		expression string
+		position   int
+		message    string
+	}{
+		{expression: "", position: 1, message: "empty expression"},
+		{expression: "rust AND", position: 9, message: "got the end of the expression"},
+		{expression: "(rust OR go", position: 12, message: `expected ")" to close the "(" at position 1`},
+		{expression: "rust)", position: 5, message: `unexpected ")"`},
+		{expression: `"rust`, position: 1, message: "unterminated \""},
+		{expression: "author:pg", position: 1, message: `unknown field "author"`},
+		{expression: "votes>10", position: 1, message: `unknown numeric field "votes"`},
+		{expression: "score>ten", position: 1, message: `"ten" is not a number`},
+		{expression: "title: OR", position: 8, message: `after "title:", got "OR"`},
+		{expression: "/[a-z/", position: 1, message: "missing closing ]"},
+	}
+
+	for _, testCase := range testCases {
+		_, err := parseExpression(testCase.expression)
+
+		var expressionErr *ExpressionError
+		if !errors.As(err, &expressionErr) {
+			t.Errorf("Expected %q to fail, got %v", testCase.expression, err)
+			continue
+		}
+
+		if expressionErr.Position != testCase.position || !strings.Contains(err.Error(), testCase.message) {
+			t.Errorf("Expected %q to fail at %d with %q, got %v", testCase.expression, testCase.position,
+				testCase.message, err)
+		}
+	}
+}
+
+func TestFilterExpression(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		Filters: []FilterItem{{Title: "Rust", Expression: "rust AND (async OR tokio) NOT job"}},
+	}}
+
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
+
+	if !fetcher.filterItem(&JsonNewsItem{Title: "Tokio 2.0 for Rust released"}) {
+		t.Errorf("Expected the expression to match")
+	}
+
+	if fetcher.filterItem(&JsonNewsItem{Title: "Rust job: async developer"}) {
+		t.Errorf("Expected the expression not to match")
+	}
+
+	fetcher.Settings.Filters = []FilterItem{{Title: "Broken", Expression: "rust AND (async"}}
+
+	err := fetcher.prepareFilters()
+	if err == nil ||
+		!strings.Contains(err.Error(), `filter "Broken" (#1), expression "rust AND (async": at position 16`) {
+		t.Errorf("Expected the broken expression to be reported, got %v", err)
+	}
+}
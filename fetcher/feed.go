@@ -0,0 +1,310 @@
+package fetcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const FeedSourceType = "feed"
+
+// Date layouts seen in the RSS feeds, RFC 822 with its common variations
+var rssDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC3339,
+}
+
+// Feed data types
+
+type rssDocument struct {
+	Channel struct {
+		Items []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			Guid        string `xml:"guid"`
+			PubDate     string `xml:"pubDate"`
+			Author      string `xml:"author"`
+			Comments    string `xml:"comments"`
+			Creator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+			Description string `xml:"description"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+type atomDocument struct {
+	Entries []struct {
+		Id        string `xml:"id"`
+		Title     string `xml:"title"`
+		Updated   string `xml:"updated"`
+		Published string `xml:"published"`
+		Summary   string `xml:"summary"`
+		Content   string `xml:"content"`
+		Author    struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+	} `xml:"entry"`
+}
+
+type jsonFeedDocument struct {
+	Items []struct {
+		Id            any    `json:"id"`
+		Url           string `json:"url"`
+		ExternalUrl   string `json:"external_url"`
+		Title         string `json:"title"`
+		ContentText   string `json:"content_text"`
+		Summary       string `json:"summary"`
+		DatePublished string `json:"date_published"`
+		Author        struct {
+			Name string `json:"name"`
+		} `json:"author"`
+		Authors []struct {
+			Name string `json:"name"`
+		} `json:"authors"`
+	} `json:"items"`
+}
+
+// FeedSource Entries of an RSS 2.0, Atom 1.0 or JSON Feed document
+type FeedSource struct {
+	name   string
+	url    string
+	items  map[int64]JsonNewsItem
+	client *HttpClient
+}
+
+func newFeedSource(_ *Configuration, config *SourceConfig, client *HttpClient) (Source, error) {
+	if config.Url == "" {
+		return nil, errors.New("the feed URL is not set")
+	}
+
+	return &FeedSource{name: config.Name, url: config.Url, client: client}, nil
+}
+
+func (s *FeedSource) Name() string {
+	return s.name
+}
+
+// Prefetch Download the feed and return its entries' IDs in the feed's order
+func (s *FeedSource) Prefetch() (*[]int64, error) {
+	var result []int64
+
+	body, err := s.client.Get(s.url)
+	if err != nil {
+		return &result, err
+	}
+
+	entries, err := parseFeed(s.url, body)
+	if err != nil {
+		return &result, err
+	}
+
+	s.items = make(map[int64]JsonNewsItem, len(entries))
+
+	for _, entry := range entries {
+		if _, seen := s.items[entry.Id]; seen {
+			continue
+		}
+
+		entry.Source = s.name
+		s.items[entry.Id] = entry
+		result = append(result, entry.Id)
+	}
+
+	return &result, nil
+}
+
+// FetchOne Return an entry of the last downloaded feed
+func (s *FeedSource) FetchOne(id int64) (JsonNewsItem, error) {
+	item, ok := s.items[id]
+	if !ok {
+		return JsonNewsItem{}, fmt.Errorf("item %d of the feed %s: %w", id, s.url, ErrNotListed)
+	}
+
+	return item, nil
+}
+
+// Parse a feed document, detecting its format. The entries' keys are only unique within their
+// feed, so their IDs are namespaced by the feed's URL: two feeds never share an ID, and renaming
+// a feed keeps the IDs of its entries.
+func parseFeed(feedURL string, body []byte) ([]JsonNewsItem, error) {
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '{' {
+		return parseJSONFeed(feedURL, trimmed)
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("not an RSS, Atom or JSON feed: %w", err)
+		}
+
+		if root, ok := token.(xml.StartElement); ok {
+			switch root.Name.Local {
+			case "rss":
+				return parseRSS(feedURL, body)
+			case "feed":
+				return parseAtom(feedURL, body)
+			default:
+				return nil, fmt.Errorf("unsupported feed format <%s>", root.Name.Local)
+			}
+		}
+	}
+}
+
+func parseRSS(feedURL string, body []byte) ([]JsonNewsItem, error) {
+	var document rssDocument
+
+	if err := xml.Unmarshal(body, &document); err != nil {
+		return nil, err
+	}
+
+	items := make([]JsonNewsItem, 0, len(document.Channel.Items))
+
+	for _, entry := range document.Channel.Items {
+		key := firstNonEmpty(entry.Guid, entry.Link, entry.Title)
+		if key == "" {
+			continue
+		}
+
+		items = append(items, JsonNewsItem{
+			Id:         namespacedID(feedURL, key),
+			Title:      strings.TrimSpace(entry.Title),
+			Url:        firstNonEmpty(entry.Link, guidLink(entry.Guid)),
+			By:         firstNonEmpty(entry.Creator, entry.Author),
+			Text:       entry.Description,
+			Time:       parseFeedTime(entry.PubDate, rssDateLayouts...),
+			Type:       "story",
+			Discussion: strings.TrimSpace(entry.Comments),
+		})
+	}
+
+	return items, nil
+}
+
+func parseAtom(feedURL string, body []byte) ([]JsonNewsItem, error) {
+	var document atomDocument
+
+	if err := xml.Unmarshal(body, &document); err != nil {
+		return nil, err
+	}
+
+	items := make([]JsonNewsItem, 0, len(document.Entries))
+
+	for _, entry := range document.Entries {
+		link := ""
+
+		for _, candidate := range entry.Links {
+			if candidate.Rel == "" || candidate.Rel == "alternate" {
+				link = candidate.Href
+				break
+			}
+		}
+
+		key := firstNonEmpty(entry.Id, link)
+		if key == "" {
+			continue
+		}
+
+		items = append(items, JsonNewsItem{
+			Id:    namespacedID(feedURL, key),
+			Title: strings.TrimSpace(entry.Title),
+			Url:   firstNonEmpty(link, guidLink(entry.Id)),
+			By:    entry.Author.Name,
+			Text:  firstNonEmpty(entry.Summary, entry.Content),
+			Time:  parseFeedTime(firstNonEmpty(entry.Published, entry.Updated), time.RFC3339),
+			Type:  "story",
+		})
+	}
+
+	return items, nil
+}
+
+func parseJSONFeed(feedURL string, body []byte) ([]JsonNewsItem, error) {
+	var document jsonFeedDocument
+
+	if err := json.Unmarshal(body, &document); err != nil {
+		return nil, err
+	}
+
+	items := make([]JsonNewsItem, 0, len(document.Items))
+
+	for _, entry := range document.Items {
+		id := ""
+		if entry.Id != nil {
+			id = fmt.Sprint(entry.Id)
+		}
+
+		link := firstNonEmpty(entry.Url, entry.ExternalUrl, guidLink(id))
+
+		key := firstNonEmpty(id, link)
+		if key == "" {
+			continue
+		}
+
+		author := entry.Author.Name
+		if author == "" && len(entry.Authors) > 0 {
+			author = entry.Authors[0].Name
+		}
+
+		items = append(items, JsonNewsItem{
+			Id:    namespacedID(feedURL, key),
+			Title: strings.TrimSpace(entry.Title),
+			Url:   link,
+			By:    author,
+			Text:  firstNonEmpty(entry.ContentText, entry.Summary),
+			Time:  parseFeedTime(entry.DatePublished, time.RFC3339),
+			Type:  "story",
+		})
+	}
+
+	return items, nil
+}
+
+// Parse a feed's date with the first layout that fits; entries without a valid date are
+// treated as published now
+func parseFeedTime(value string, layouts ...string) int64 {
+	value = strings.TrimSpace(value)
+
+	for _, layout := range layouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed.Unix()
+		}
+	}
+
+	return time.Now().Unix()
+}
+
+// Link of an entry that has none, its GUID or ID, if that is a web address, like a permalink
+func guidLink(guid string) string {
+	parsedURL, err := url.Parse(strings.TrimSpace(guid))
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return ""
+	}
+
+	return parsedURL.String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,202 @@
+package fetcher
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+)
+
+const (
+	testRSSFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<channel>
+  <title>Some Blog</title>
+  <item>
+    <title>Some Title #1</title>
+    <link>https://some-host/1-story-uri/</link>
+    <guid>https://some-host/?p=1</guid>
+    <pubDate>Sat, 15 Oct 2022 13:08:59 +0000</pubDate>
+    <dc:creator>author</dc:creator>
+    <description>Story #1</description>
+  </item>
+  <item>
+    <title>Some News</title>
+    <link>https://some-host/2-story-uri/</link>
+  </item>
+</channel>
+</rss>`
+	testAtomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Some Blog</title>
+  <entry>
+    <id>tag:some-host,2022:1</id>
+    <title>Some Title #1</title>
+    <link rel="alternate" href="https://some-host/1-story-uri/"/>
+    <link rel="replies" href="https://some-host/1-story-uri/#comments"/>
+    <published>2022-10-15T13:08:59Z</published>
+    <author><name>author</name></author>
+    <summary>Story #1</summary>
+  </entry>
+</feed>`
+	testJSONFeed = `{
+  "version": "https://jsonfeed.org/version/1.1",
+  "title": "Some Blog",
+  "items": [
+    {
+      "id": "1",
+      "url": "https://some-host/1-story-uri/",
+      "title": "Some Title #1",
+      "content_text": "Story #1",
+      "date_published": "2022-10-15T13:08:59Z",
+      "authors": [{"name": "author"}]
+    }
+  ]
+}`
+)
+
+func TestFeedFormats(t *testing.T) {
+	for name, document := range map[string]string{"rss": testRSSFeed, "atom": testAtomFeed, "json": testJSONFeed} {
+		items, err := parseFeed(FeedSourceType, []byte(document))
+		if err != nil {
+			t.Fatalf("%s: could not parse the feed, %v", name, err)
+		}
+
+		if len(items) == 0 {
+			t.Fatalf("%s: no items parsed", name)
+		}
+
+		item := items[0]
+
+		if item.Title != "Some Title #1" || item.Url != "https://some-host/1-story-uri/" {
+			t.Errorf("%s: unexpected title or link: %s - %s", name, item.Title, item.Url)
+		}
+
+		if item.By != "author" || item.Text != "Story #1" {
+			t.Errorf("%s: unexpected author or text: %s, %s", name, item.By, item.Text)
+		}
+
+		if item.Time != 1665839339 {
+			t.Errorf("%s: expected the item time to be %d, got %d", name, 1665839339, item.Time)
+		}
+
+		if item.Id&namespacedIDBit == 0 {
+			t.Errorf("%s: the item ID %d may collide with HackerNews IDs", name, item.Id)
+		}
+	}
+}
+
+func TestFeedEntriesWithoutLinks(t *testing.T) {
+	items, err := parseFeed(FeedSourceType, []byte(`<rss version="2.0"><channel>
+  <item><title>Permalink</title><guid>https://some-host/?p=3</guid></item>
+  <item><title>Tagged</title><guid>tag:some-host,2022:4</guid></item>
+</channel></rss>`))
+	if err != nil {
+		t.Fatalf("Could not parse the feed, %v", err)
+	}
+
+	if len(items) != 2 || items[0].Url != "https://some-host/?p=3" || items[1].Url != "" {
+		t.Errorf("Expected the GUID that is a web address to be the link, got %v", items)
+	}
+
+	items, err = parseFeed(FeedSourceType, []byte(`<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry><id>https://some-host/5</id><title>Atom Permalink</title></entry>
+</feed>`))
+	if err != nil {
+		t.Fatalf("Could not parse the feed, %v", err)
+	}
+
+	if len(items) != 1 || items[0].Url != "https://some-host/5" {
+		t.Errorf("Expected the ID that is a web address to be the link, got %v", items)
+	}
+}
+
+func TestFeedUnknownFormat(t *testing.T) {
+	if _, err := parseFeed(FeedSourceType, []byte("<html><body>Not a feed</body></html>")); err == nil {
+		t.Error("Expected an HTML page to fail parsing")
+	}
+}
+
+func TestFeedSource(t *testing.T) {
+	const feedURL = "http://some-host/feed.xml"
+
+	sources, err := newSources(&Configuration{Sources: []SourceConfig{
+		{Type: FeedSourceType, Name: "blog", Url: feedURL},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := sources[0]
+
+	httpmock.Activate()
+
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", feedURL, httpmock.NewStringResponder(200, testRSSFeed))
+
+	prefetched, err := source.Prefetch()
+	if err != nil {
+		t.Fatalf("Error while prefetching the feed, %v", err)
+	}
+
+	if len(*prefetched) != 2 {
+		t.Fatalf("Expected 2 feed entries, got %d", len(*prefetched))
+	}
+
+	// IDs are stable between the downloads
+	if again, _ := source.Prefetch(); (*again)[0] != (*prefetched)[0] || (*again)[1] != (*prefetched)[1] {
+		t.Errorf("Expected the same IDs, got %v and %v", *prefetched, *again)
+	}
+
+	item, err := source.FetchOne((*prefetched)[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if item.Title != "Some News" || item.Source != "blog" {
+		t.Errorf("Unexpected item %s from %s", item.Title, item.Source)
+	}
+
+	// The entry without a GUID gets its ID from the link, namespaced by the feed's URL
+	if item.Id != namespacedID(feedURL, "https://some-host/2-story-uri/") {
+		t.Errorf("Expected the ID to be the hash of the link")
+	}
+
+	if _, err := source.FetchOne(1); !errors.Is(err, ErrNotListed) {
+		t.Errorf("Expected an item not in the feed to be unlisted, got %v", err)
+	}
+}
+
+func TestFeedItemsAreUnscored(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		MinScore:    10,
+		MinComments: 5,
+		Filters:     []FilterItem{{Title: "Test filter", Value: "title"}},
+		Sources:     []SourceConfig{{Type: HackerNewsSourceType}, {Type: FeedSourceType, Name: "blog"}},
+	}}
+
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatal(err)
+	}
+
+	item := JsonNewsItem{Title: "Some Title", Url: "http://host/1", Time: time.Now().Unix(), Source: "blog"}
+
+	// Feeds have no points or comments, so only the age limits apply to their entries
+	if verdict := fetcher.evaluate(&item); verdict != verdictInclude {
+		t.Errorf("Expected the feed entry to be included, got %v", verdict)
+	}
+
+	item.Source = HackerNewsSourceType
+
+	if verdict := fetcher.evaluate(&item); verdict != verdictDefer {
+		t.Errorf("Expected the HackerNews item to be deferred, got %v", verdict)
+	}
+}
+
+func TestFeedSourceWithoutUrl(t *testing.T) {
+	if _, err := newSources(&Configuration{Sources: []SourceConfig{{Type: FeedSourceType}}}); err == nil {
+		t.Error("Expected a feed source without a URL to fail")
+	}
+}
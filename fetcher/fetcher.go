@@ -1,13 +1,11 @@
 package fetcher
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"net/http"
-	"net/url"
-	"regexp"
-	"strings"
+	"sync"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"golang.org/x/exp/slices"
@@ -15,163 +13,459 @@ import (
 
 // Constants
 
-const RegexCaseInsensitive = "(?i)"
+const (
+	RegexCaseInsensitive    = "(?i)"
+	DefaultFetchConcurrency = 8
+	// How long items that do not meet the thresholds yet stay pending, unless configured
+	DefaultPendingWindowHours = 24
+)
 
 // Methods
 
 type Fetcher struct {
-	filters    []string
-	Settings   Configuration
-	repository DataRepository
-	Reverse    bool
+	filters   []compiledFilter
+	blacklist []domainPattern
+	whitelist []domainPattern
+	// Items of the run, stored once the digest has been sent
+	fetched    []DigestItem
+	deferred   []DigestItem
+	failed     []fetchFailure
+	unlisted   []int64
+	classifier *classifierModel
+	// Sources that pulled the items of the run, by the items' IDs
+	pulled map[int64]string
+	// Canonical URLs of the digest items of the run, to tell the reposts within it
+	digestLinks map[string]bool
+	// Title shingles of the stories delivered lately
+	deliveredTitles []titleShingles
+	Settings        Configuration
+	repository      DataRepository
+	Reverse         bool
 }
 
-// Parse the filters configuration and return it as a flat array of strings
-func (f *Fetcher) prepareFilters() []string {
-	var resultFilters []string
-
-	for _, filter := range f.Settings.Filters {
-		filterStrings := strings.Split(filter.Value, ",")
-		resultFilters = append(resultFilters, filterStrings...)
+// Parse the filters configuration and compile the filters' patterns and the domain lists
+func (f *Fetcher) prepareFilters() error {
+	filters, err := compileFilters(f.Settings.Filters)
+	if err != nil {
+		return err
 	}
 
-	return resultFilters
-}
+	blacklist, err := compileDomains(f.Settings.BlacklistedDomains)
+	if err != nil {
+		return fmt.Errorf("blacklisted domains: %w", err)
+	}
 
-// Get top stories' IDs
-func (f *Fetcher) prefetch() (*[]int64, error) {
-	var result []int64
+	whitelist, err := compileDomains(f.Settings.WhitelistedDomains)
+	if err != nil {
+		return fmt.Errorf("whitelisted domains: %w", err)
+	}
 
-	prefetchURL := fmt.Sprintf("%s/topstories.json", f.Settings.ApiBaseUrl)
-	request, _ := http.NewRequest(http.MethodGet, prefetchURL, http.NoBody)
-	resp, err := http.DefaultClient.Do(request)
+	if err := validateClassifier(&f.Settings.Classifier); err != nil {
+		return err
+	}
 
-	if err != nil {
-		return &result, err
+	if err := validateDedup(&f.Settings.Dedup); err != nil {
+		return err
 	}
 
-	defer resp.Body.Close()
+	f.filters, f.blacklist, f.whitelist = filters, blacklist, whitelist
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return &result, err
+	return nil
+}
+
+// Number of workers used to fetch news items in parallel
+func (f *Fetcher) concurrency() int {
+	if f.Settings.Fetch.Concurrency == 0 {
+		return DefaultFetchConcurrency
 	}
 
-	return &result, nil
+	return int(f.Settings.Fetch.Concurrency)
 }
 
-// Fetch one news item as a JSON object
-func (f *Fetcher) fetchOne(id int64) (JsonNewsItem, error) {
-	var result JsonNewsItem
+// Fetch the news items with a bounded pool of workers. The results keep the order
+// of the provided IDs, so the digest follows the ranking of the prefetched list.
+func (f *Fetcher) fetchAll(source Source, ids []int64) []fetchResult {
+	results := make([]fetchResult, len(ids))
+	jobs := make(chan int)
 
-	prefetchURL := fmt.Sprintf("%s/item/%d.json", f.Settings.ApiBaseUrl, id)
-	resp, err := http.Get(prefetchURL)
+	var wg sync.WaitGroup
 
-	if err != nil {
-		return result, err
-	}
+	for range min(f.concurrency(), len(ids)) {
+		wg.Add(1)
 
-	defer resp.Body.Close()
+		go func() {
+			defer wg.Done()
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return result, err
+			for idx := range jobs {
+				item, err := source.FetchOne(ids[idx])
+				results[idx] = fetchResult{item: item, err: err}
+			}
+		}()
 	}
 
-	return result, nil
+	for idx := range ids {
+		jobs <- idx
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	return results
 }
 
 // Load IDs for news items that are already in the repository. For those prefetched IDs
-// those that are not in the repository yet, fetch them one by one and run against
+// those that are not in the repository yet, fetch them from the source and run against
 // the set of filters. For the reverse'd filters, the news item must be in none of them.
-func (f *Fetcher) filter(prefetched *[]int64) (*[]DigestItem, *[]DigestItem, error) {
+func (f *Fetcher) filter(source Source, prefetched *[]int64) (*[]DigestItem, *[]DigestItem, error) {
 	var (
 		newItems    []DigestItem
 		digestItems []DigestItem
 	)
 
-	// Find items to pull
-	idsToPull, err := f.repository.GetIDsToPull(prefetched)
+	idsToPull, err := f.selectIDs(source, prefetched)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	// Fetch news items which do not exist in the DB
-	for _, fetchID := range idsToPull {
-		// Fetch a new
-		newItem, err := f.fetchOne(fetchID)
-		if err != nil {
-			log.Println("FETCH_ONE: ", err)
+	for idx, fetched := range f.fetchAll(source, idsToPull) {
+		// The items the source does not list anymore can never be fetched, so they are given up
+		if errors.Is(fetched.err, ErrNotListed) {
+			log.Println("FETCH_ONE: ", fetched.err)
+			f.unlisted = append(f.unlisted, idsToPull[idx])
+
+			continue
 		}
 
-		// Set a dumb URL and Title for items that don't have a URL
-		if newItem.Url == "" {
-			newItems = append(newItems, DigestItem{
-				id:        newItem.Id,
-				createdAt: newItem.Time,
-				newsTitle: "-",
-				newsUrl:   "-",
-			})
-		} else {
-			// And now the valid items can be processed
-			digestItem := DigestItem{
-				id:        newItem.Id,
-				createdAt: newItem.Time,
-				newsTitle: newItem.Title,
-				newsUrl:   newItem.Url,
-			}
+		// Failed items are not recorded as seen, but retried on the next runs
+		if fetched.err != nil {
+			log.Println("FETCH_ONE: ", fetched.err)
+			f.failed = append(f.failed, fetchFailure{id: idsToPull[idx], err: fetched.err, source: source.Name()})
 
-			newItems = append(newItems, digestItem)
+			continue
+		}
 
-			if f.filterItem(&newItem) && f.filterBlacklisted(&newItem) {
-				digestItems = append(digestItems, digestItem)
-			}
+		digestItem, result, err := f.triage(&fetched.item)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch result {
+		case verdictInclude:
+			// Recorded as seen until the digest has been sent
+			newItems = append(newItems, digestItem)
+			digestItems = append(digestItems, digestItem)
+		case verdictDefer:
+			// Kept pending, so that it is fetched and checked again on a later run
+			f.deferred = append(f.deferred, digestItem)
+		case verdictExpire:
+			digestItem.status = StatusExpired
+			newItems = append(newItems, digestItem)
+		case verdictSkip:
+			newItems = append(newItems, digestItem)
 		}
 	}
 
 	return &newItems, &digestItems, nil
 }
 
-// Run a news item against the blacklisted domains
-func (f *Fetcher) filterBlacklisted(newItem *JsonNewsItem) bool {
-	if len(f.Settings.BlacklistedDomains) == 0 {
-		// No blackist - nothing to check
-		return true
+// IDs of the source's items to pull: those not in the repository yet, and not pulled by a sharing
+// source in this run, followed by the pending and the failed ones
+func (f *Fetcher) selectIDs(source Source, prefetched *[]int64) ([]int64, error) {
+	if f.pulled == nil {
+		f.pulled = map[int64]string{}
 	}
 
-	parsedURL, err := url.Parse(newItem.Url)
+	sharing := sharingSources(&f.Settings, source.Name())
 
+	idsToPull, err := f.repository.GetIDsToPull(source.Name(), prefetched, sharing...)
 	if err != nil {
-		// Failed to parse the URL - not in the blacklist then
-		return true
+		return nil, err
 	}
 
-	return !slices.Contains(f.Settings.BlacklistedDomains, parsedURL.Host)
+	// The run's items are not stored yet, so those pulled from a sharing source are skipped here
+	idsToPull = slices.DeleteFunc(idsToPull, func(id int64) bool {
+		return slices.Contains(sharing, f.pulled[id])
+	})
+
+	for _, id := range idsToPull {
+		f.pulled[id] = source.Name()
+	}
+
+	return idsToPull, nil
 }
 
-// Run a news item against all the configured filters
-func (f *Fetcher) filterItem(newItem *JsonNewsItem) bool {
+// Digest item of a fetched news item, and whether it is included in the digest, kept pending,
+// expired or only recorded as seen
+func (f *Fetcher) triage(newItem *JsonNewsItem) (DigestItem, verdict, error) {
+	digestItem := newDigestItem(newItem)
+
+	// Deleted and dead items have nothing to filter on, so they are only recorded as seen
+	if newItem.Title == "" || newItem.Url == "" {
+		digestItem.newsTitle = "-"
+		digestItem.newsUrl = "-"
+
+		return digestItem, verdictSkip, nil
+	}
+
+	// And now the valid items can be processed
+	if result := f.evaluate(newItem); result != verdictInclude {
+		return digestItem, result, nil
+	}
+
+	matched := f.matchingFilters(newItem)
+	digestItem.filters = filterTitles(matched)
+	digestItem.relevance = f.relevance(newItem, matched, time.Now())
+
+	// Not relevant enough, so only recorded as seen
+	if f.belowCutoff(digestItem.relevance) {
+		return digestItem, verdictSkip, nil
+	}
+
+	// A repost of an article delivered lately is only recorded as seen
+	repost, err := f.isRepost(&digestItem)
+	if err != nil {
+		return digestItem, verdictSkip, err
+	}
+
+	// So is a story delivered lately under another title
+	if repost || f.similarToDelivered(digestItem.newsTitle) {
+		return digestItem, verdictSkip, nil
+	}
+
+	f.addDigestLink(&digestItem)
+
+	return digestItem, verdictInclude, nil
+}
+
+// Digest item of a news item, not matched against the filters yet
+func newDigestItem(newItem *JsonNewsItem) DigestItem {
+	return DigestItem{
+		id:            newItem.Id,
+		createdAt:     newItem.Time,
+		newsTitle:     newItem.Title,
+		newsUrl:       newItem.Url,
+		newsText:      newItem.Text,
+		author:        newItem.By,
+		itemType:      newItem.Type,
+		score:         newItem.Score,
+		comments:      newItem.Descendants,
+		source:        newItem.Source,
+		lists:         newItem.Lists,
+		tags:          newItem.Tags,
+		discussionUrl: newItem.Discussion,
+		canonicalUrl:  canonicalURL(newItem.Url),
+	}
+}
+
+// Whether the digest item links to an article delivered within the repost window, or already
+// in the digest of the run
+func (f *Fetcher) isRepost(item *DigestItem) (bool, error) {
+	window := optional(f.Settings.Dedup.WindowDays, DefaultRepostWindowDays)
+
+	if item.canonicalUrl == "" || window == 0 {
+		return false, nil
+	}
+
+	if f.digestLinks[item.canonicalUrl] {
+		return true, nil
+	}
+
+	return f.repository.DeliveredSince(item.canonicalUrl, time.Now().AddDate(0, 0, -int(window)))
+}
+
+// Add the digest item's link to the links of the run's digest
+func (f *Fetcher) addDigestLink(item *DigestItem) {
+	if f.digestLinks == nil {
+		f.digestLinks = map[string]bool{}
+	}
+
+	f.digestLinks[item.canonicalUrl] = true
+}
+
+// Run a news item against the filters, the blacklist and the thresholds
+func (f *Fetcher) evaluate(newItem *JsonNewsItem) verdict {
+	if !f.Reverse && f.isWhitelisted(newItem) {
+		// Trusted domains get in whatever the filters say, so only the global thresholds apply.
+		// The reverse mode is for the items the filters leave out, so the whitelist is skipped there.
+		return f.checkThresholds(newItem, f.thresholdsFor(&FilterItem{}))
+	}
+
+	if !f.filterItem(newItem) || !f.filterBlacklisted(newItem) {
+		return verdictSkip
+	}
+
 	if f.Reverse {
-		anyFilterHit := false
+		// No filter hits in the reverse mode, so only the global thresholds apply
+		return f.checkThresholds(newItem, f.thresholdsFor(&FilterItem{}))
+	}
 
-		for _, filter := range f.filters {
-			if hit, _ := regexp.MatchString(RegexCaseInsensitive+filter, newItem.Title); hit {
-				return false
+	result := verdictSkip
+
+	// The item qualifies if it meets the thresholds of any filter it matched,
+	// and stays pending while any of them can still be met
+	for _, filter := range f.matchingFilters(newItem) {
+		switch f.checkThresholds(newItem, f.thresholdsFor(&filter)) {
+		case verdictInclude:
+			return verdictInclude
+		case verdictDefer:
+			result = verdictDefer
+		case verdictExpire:
+			if result == verdictSkip {
+				result = verdictExpire
 			}
+		case verdictSkip:
 		}
+	}
 
-		return !anyFilterHit
+	return result
+}
+
+// Filters that matched a news item and why, including the matches vetoed by exclude patterns
+func (f *Fetcher) explainFilters(newItem *JsonNewsItem) []FilterMatch {
+	var matches []FilterMatch
+
+	for idx := range f.filters {
+		if match, hit := f.filters[idx].explain(newItem); hit {
+			matches = append(matches, match)
+		}
 	}
 
-	for _, filterItem := range f.filters {
-		if hit, _ := regexp.MatchString(RegexCaseInsensitive+filterItem, newItem.Title); hit {
-			return true
+	if probability, hit := f.classify(newItem); hit {
+		matches = append(matches, FilterMatch{Title: classifierFilter(&f.Settings.Classifier).Title,
+			Matched: fmt.Sprintf("classifier at %.0f%% confidence", probability*100)})
+	}
+
+	return matches
+}
+
+// Filters whose patterns or expressions match a news item, and the classifier if it is confident
+// the item is relevant
+func (f *Fetcher) matchingFilters(newItem *JsonNewsItem) []FilterItem {
+	var matched []FilterItem
+
+	for idx := range f.filters {
+		if f.filters[idx].matches(newItem) {
+			matched = append(matched, f.filters[idx].FilterItem)
 		}
 	}
 
-	return false
+	if _, hit := f.classify(newItem); hit {
+		matched = append(matched, classifierFilter(&f.Settings.Classifier))
+	}
+
+	return matched
+}
+
+// Titles of the provided filters
+func filterTitles(filters []FilterItem) []string {
+	titles := make([]string, 0, len(filters))
+
+	for _, filter := range filters {
+		titles = append(titles, filter.Title)
+	}
+
+	return titles
+}
+
+// Thresholds of a filter, with the global ones used for the limits the filter does not set
+func (f *Fetcher) thresholdsFor(filter *FilterItem) Thresholds {
+	limits := Thresholds{
+		MinScore:    f.Settings.MinScore,
+		MinComments: f.Settings.MinComments,
+		MaxAgeHours: f.Settings.MaxAgeHours,
+	}
+
+	if filter.MinScore != 0 {
+		limits.MinScore = filter.MinScore
+	}
+
+	if filter.MinComments != 0 {
+		limits.MinComments = filter.MinComments
+	}
+
+	if filter.MaxAgeHours != 0 {
+		limits.MaxAgeHours = filter.MaxAgeHours
+	}
+
+	return limits
+}
+
+// How long an item that does not meet the thresholds yet stays pending
+func (f *Fetcher) pendingWindow(limits Thresholds) uint {
+	window := f.Settings.Pending.WindowHours
+	if window == 0 {
+		window = DefaultPendingWindowHours
+	}
+
+	if limits.MaxAgeHours > 0 && limits.MaxAgeHours < window {
+		return limits.MaxAgeHours
+	}
+
+	return window
+}
+
+// Check a news item against the thresholds. Items that are too old expire; items
+// that are young enough but do not have the score or comments yet are deferred.
+func (f *Fetcher) checkThresholds(newItem *JsonNewsItem, limits Thresholds) verdict {
+	ageHours := time.Since(time.Unix(newItem.Time, 0)).Hours()
+
+	// The items of the sources without points and comments could never meet their limits
+	if f.unscored(newItem) {
+		limits.MinScore, limits.MinComments = 0, 0
+	}
+
+	if limits.MaxAgeHours > 0 && ageHours > float64(limits.MaxAgeHours) {
+		return verdictExpire
+	}
+
+	if newItem.Score >= limits.MinScore && newItem.Descendants >= limits.MinComments {
+		return verdictInclude
+	}
+
+	if ageHours > float64(f.pendingWindow(limits)) {
+		return verdictExpire
+	}
+
+	return verdictDefer
+}
+
+// Whether a news item comes from a source that has no points and comments, like a feed
+func (f *Fetcher) unscored(newItem *JsonNewsItem) bool {
+	configs := sourceConfigs(&f.Settings)
+
+	idx := slices.IndexFunc(configs, func(config SourceConfig) bool { return config.Name == newItem.Source })
+
+	return idx >= 0 && slices.Contains(UnscoredSourceTypes, configs[idx].Type)
+}
+
+// Run a news item against the blacklisted domains
+func (f *Fetcher) filterBlacklisted(newItem *JsonNewsItem) bool {
+	_, blacklisted := matchDomain(f.blacklist, urlHost(newItem.Url))
+
+	return !blacklisted
+}
+
+// Check whether a news item comes from a whitelisted domain
+func (f *Fetcher) isWhitelisted(newItem *JsonNewsItem) bool {
+	_, whitelisted := matchDomain(f.whitelist, urlHost(newItem.Url))
+
+	return whitelisted
+}
+
+// Run a news item against all the configured filters
+func (f *Fetcher) filterItem(newItem *JsonNewsItem) bool {
+	anyFilterHit := len(f.matchingFilters(newItem)) > 0
+
+	if f.Reverse {
+		return !anyFilterHit
+	}
+
+	return anyFilterHit
 }
 
 // Compile an email from the provided news list and send it
-func (f *Fetcher) SendEmail(digest *[]DigestItem) {
+func (f *Fetcher) SendEmail(digest *[]DigestGroup) {
 	subjectPostfix := ""
 
 	if f.Reverse {
@@ -183,7 +477,7 @@ func (f *Fetcher) SendEmail(digest *[]DigestItem) {
 }
 
 // Send to Telegram from the provided news list and send it
-func (f *Fetcher) SendTelegram(digest *[]DigestItem) {
+func (f *Fetcher) SendTelegram(digest *[]DigestGroup) {
 	telegram := DigestTelegram{tgConfig: f.Settings.Telegram}
 	telegram.SendTelegram(digest, f.Settings.Telegram)
 }
@@ -200,13 +494,79 @@ func (f *Fetcher) Vacuum() error {
 }
 
 func (f *Fetcher) setUpRepository() error {
-	f.repository = DataRepository{dbConfig: f.Settings.Database, purgeAfter: f.Settings.PurgeAfterDays, reverse: f.Reverse}
+	f.repository = DataRepository{
+		dbConfig:   f.Settings.Database,
+		pending:    f.Settings.Pending,
+		purgeAfter: f.Settings.PurgeAfterDays,
+		maxRetries: f.Settings.Fetch.MaxRetries,
+		reverse:    f.Reverse,
+	}
 	return f.repository.Init()
 }
 
+// Prefetch a source's items and run the new ones through the filters. Nothing is stored until
+// the digest has been sent, so the fetched, pending and failed items are kept for store.
+func (f *Fetcher) runSource(source Source) ([]DigestItem, error) {
+	prefetchedItems, err := source.Prefetch()
+	if err != nil {
+		return nil, fmt.Errorf("could not prefetch %s: %w", source.Name(), err)
+	}
+
+	filteredItems, digest, err := f.filter(source, prefetchedItems)
+	if err != nil {
+		return nil, err
+	}
+
+	f.fetched = append(f.fetched, *filteredItems...)
+
+	return *digest, nil
+}
+
+// Store the items of the run: the fetched ones as seen (or expired), the pending ones for the
+// later runs, and the failed ones to retry; the earlier failures of the fetched ones are forgotten.
+// The pending items their sources do not list anymore expire.
+func (f *Fetcher) store() error {
+	if len(f.fetched) > 0 {
+		if err := f.repository.UpdateItems(&f.fetched); err != nil {
+			return fmt.Errorf("could not update the repository")
+		}
+	}
+
+	if len(f.deferred) > 0 {
+		if err := f.repository.DeferItems(&f.deferred); err != nil {
+			return fmt.Errorf("could not store the pending items")
+		}
+	}
+
+	if len(f.failed) > 0 {
+		if err := f.repository.RecordFailures(&f.failed); err != nil {
+			return fmt.Errorf("could not record the failed items")
+		}
+	}
+
+	if err := f.repository.ExpireItems(f.unlisted); err != nil {
+		return fmt.Errorf("could not expire the unlisted items")
+	}
+
+	fetchedIDs := make([]int64, 0, len(f.fetched)+len(f.deferred)+len(f.unlisted))
+	for _, item := range append(f.fetched, f.deferred...) {
+		fetchedIDs = append(fetchedIDs, item.id)
+	}
+
+	fetchedIDs = append(fetchedIDs, f.unlisted...)
+
+	if err := f.repository.ClearFailures(fetchedIDs); err != nil {
+		return fmt.Errorf("could not clear the failed items")
+	}
+
+	return nil
+}
+
 // The main runner function
 func (f *Fetcher) Run() (*Results, error) {
-	f.filters = f.prepareFilters()
+	if err := f.prepareFilters(); err != nil {
+		return nil, err
+	}
 
 	if err := f.setUpRepository(); err != nil {
 		return nil, err
@@ -214,41 +574,97 @@ func (f *Fetcher) Run() (*Results, error) {
 
 	defer f.repository.Close()
 
-	prefetchedItems, err := f.prefetch()
-	if err != nil {
+	if err := f.loadClassifier(&f.repository); err != nil {
+		return nil, err
+	}
+
+	if err := f.loadDeliveredTitles(); err != nil {
 		return nil, err
 	}
 
-	filteredItems, digest, err := f.filter(prefetchedItems)
+	sources, err := newSources(&f.Settings)
 	if err != nil {
 		return nil, err
 	}
 
-	// Add newly fetched items into the repository
-	if len(*filteredItems) > 0 {
-		if err := f.repository.UpdateItems(filteredItems); err != nil {
-			return nil, fmt.Errorf("could not update the repository")
+	var digest []DigestItem
+
+	for _, source := range sources {
+		// A failing source does not hold back the others; its items are fetched on the next run
+		sourceDigest, err := f.runSource(source)
+		if err != nil {
+			log.Println("SOURCE: ", err)
+			continue
 		}
+
+		digest = append(digest, sourceDigest...)
+	}
+
+	digest, err = f.deliver(digest)
+	if err != nil {
+		return nil, err
 	}
 
 	results := &Results{
-		NewItems: len(*digest),
+		NewItems: len(digest),
 		Filters:  len(f.filters),
+		Deferred: len(f.deferred),
+		Failed:   len(f.failed),
 	}
 
-	if len(*digest) > 0 {
+	return results, nil
+}
+
+// IDs of the digest items and their related items
+func digestIDs(digest []DigestItem) []int64 {
+	ids := make([]int64, 0, len(digest))
+
+	for _, item := range digest {
+		ids = append(ids, item.id)
+		ids = append(ids, digestIDs(item.related)...)
+	}
+
+	return ids
+}
+
+// Cluster the similar stories of the digest, rank it and send it out, then store the items of
+// the run and record the digest's ones as delivered. Nothing is stored before the digest has been
+// sent, so that no item is taken for delivered unless it was. The items over the digest's cap are
+// not delivered, only seen. Returns the delivered items.
+func (f *Fetcher) deliver(digest []DigestItem) ([]DigestItem, error) {
+	digest, _ = f.rankDigest(f.clusterDigest(digest))
+
+	if len(digest) > 0 {
+		groups := groupDigest(digest, groupOrder(&f.Settings))
+
 		switch {
 		case f.Settings.Telegram.Token != "" && f.Settings.Telegram.ChatId != "":
-			f.SendTelegram(digest)
+			f.SendTelegram(&groups)
 		case f.Settings.EmailTo != "":
-			f.SendEmail(digest)
+			f.SendEmail(&groups)
 		default:
 			// Print out to console
-			for _, digestItem := range *digest {
-				fmt.Printf("* %s - %s\n", digestItem.newsTitle, digestItem.newsUrl)
+			for _, group := range groups {
+				if group.Title != "" {
+					fmt.Printf("\n%s:\n", group.Title)
+				}
+
+				for _, digestItem := range group.Items {
+					fmt.Printf("* %s%s - %s (%s)%s%s\n", digestItem.listsLabel(), digestItem.newsTitle,
+						digestItem.newsUrl, digestItem.statsLabel(), digestItem.discussionLabel(),
+						digestItem.relatedLabel())
+				}
 			}
 		}
 	}
 
-	return results, nil
+	if err := f.store(); err != nil {
+		return nil, err
+	}
+
+	if err := f.repository.SetStatus(digestIDs(digest), StatusDelivered); err != nil {
+		return nil, fmt.Errorf("could not record the delivered items")
+	}
+
+	return digest, nil
 }
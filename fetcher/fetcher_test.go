@@ -1,8 +1,11 @@
 package fetcher
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/jarcoal/httpmock"
 )
@@ -33,7 +36,9 @@ func TestRunFilterHit(t *testing.T) {
 		},
 	}
 	fetcher := Fetcher{Settings: config, Reverse: false}
-	fetcher.filters = fetcher.prepareFilters()
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
 
 	if len(fetcher.filters) != 1 {
 		t.Fatal("Wrong number of filters")
@@ -55,7 +60,9 @@ func TestRunFilterMiss(t *testing.T) {
 		},
 	}
 	fetcher := Fetcher{Settings: config, Reverse: false}
-	fetcher.filters = fetcher.prepareFilters()
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
 
 	if len(fetcher.filters) != 1 {
 		t.Fatal("Wrong number of filters")
@@ -76,7 +83,9 @@ func TestReverseFilterHit(t *testing.T) {
 			},
 		},
 	}, Reverse: true}
-	fetcher.filters = fetcher.prepareFilters()
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
 
 	if len(fetcher.filters) != 1 {
 		t.Fatal("Wrong number of filters", len(fetcher.filters))
@@ -98,7 +107,9 @@ func TestReverseFilterMiss(t *testing.T) {
 		},
 	}
 	fetcher := Fetcher{Settings: config, Reverse: true}
-	fetcher.filters = fetcher.prepareFilters()
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
 
 	if len(fetcher.filters) != 1 {
 		t.Fatal("Wrong number of filters")
@@ -119,117 +130,48 @@ func TestPrepareFilters(t *testing.T) {
 		},
 	}
 	fetcher := Fetcher{Settings: config}
-	fetcher.filters = fetcher.prepareFilters()
-
-	if len(fetcher.filters) != 2 {
-		t.Fatal("Wrong number of filters")
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
 	}
 
-	if fetcher.filters[0] != "some" || fetcher.filters[1] != "word" {
-		t.Fatal("Wrong filter values")
+	if len(fetcher.filters) != 1 || len(fetcher.filters[0].patterns) != 2 {
+		t.Fatal("Wrong number of filters")
 	}
-}
-
-func TestPrefetch(t *testing.T) {
-	const expected = "[33214439,33215770]"
 
-	fetcher := Fetcher{Settings: Configuration{ApiBaseUrl: ""}}
+	patterns := fetcher.filters[0].patterns
 
-	httpmock.Activate()
-
-	defer httpmock.DeactivateAndReset()
-
-	httpmock.RegisterResponder("GET", fetcher.Settings.ApiBaseUrl+"/topstories.json",
-		httpmock.NewStringResponder(200, expected))
-
-	value, _ := fetcher.prefetch()
-
-	if httpmock.GetTotalCallCount() < 1 {
-		t.Errorf("Expected a request while prefetching news items")
-	}
-
-	if len(*value) != 2 {
-		t.Errorf("Expected 2 prefetched items, got %d", len(*value))
-	}
-
-	if (*value)[0] != 33214439 || (*value)[1] != 33215770 {
-		t.Errorf("Expected %s prefetched items, got %v", expected, *value)
+	if fetcher.filters[0].Title != "MissTest" || patterns[0].value != "some" || patterns[1].value != "word" {
+		t.Fatal("Wrong filter values")
 	}
 }
 
-func TestFetchOne(t *testing.T) {
-	const newsID = int64(33214439)
-
-	expected := `{
-		"by": "endorphine",
-		"descendants": 1,
-		"id": 33214439,
-		"kids": [
-			33216431
-		],
-		"score": 289,
-		"time": 1665839339,
-		"title": "A 24-year-old bug in the Linux Kernel TCP stack (2021)",
-		"type": "story",
-		"url": "https://engineering.skroutz.gr/blog/uncovering-a-24-year-old-bug-in-the-linux-kernel/"
-	}`
-	fetcher := Fetcher{Settings: Configuration{ApiBaseUrl: ""}}
-
-	httpmock.Activate()
-
-	defer httpmock.DeactivateAndReset()
-
-	httpmock.RegisterResponder("GET", fetcher.Settings.ApiBaseUrl+"/item/33214439.json",
-		httpmock.NewStringResponder(200, expected))
+func TestPrepareFiltersInvalidPattern(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		Filters: []FilterItem{
+			{Title: "Linux", Value: "linux"},
+			{Title: "Hackers", Value: "hack,crack(er"},
+		},
+	}}
 
-	item, err := fetcher.fetchOne(newsID)
+	err := fetcher.prepareFilters()
 
-	if err != nil {
-		t.Error(err)
+	var filterErr *FilterError
+	if !errors.As(err, &filterErr) {
+		t.Fatalf("Expected a filter error, got %v", err)
 	}
 
-	if httpmock.GetTotalCallCount() < 1 {
-		t.Errorf("Expected a request while prefetching news items")
+	if filterErr.Title != "Hackers" || filterErr.Position != 2 || filterErr.PatternPosition != 2 {
+		t.Errorf("Expected the second pattern of the second filter to fail, got %v", err)
 	}
 
-	if item.Id != newsID {
-		t.Errorf("Expected ID to be %d, got %d", newsID, item.Id)
+	if !strings.Contains(err.Error(), `filter "Hackers" (#2), pattern "crack(er" (#2)`) {
+		t.Errorf("Expected the filter to be named in the error, got %v", err)
 	}
 
-	if item.Title != "A 24-year-old bug in the Linux Kernel TCP stack (2021)" {
-		t.Errorf("Expected ID to be %s, got %s", "A 24-year-old bug in the Linux Kernel TCP stack (2021)", item.Title)
-	}
+	fetcher.Settings.Filters = []FilterItem{{Title: "Trailing", Value: "linux,"}}
 
-	if item.Url != "https://engineering.skroutz.gr/blog/uncovering-a-24-year-old-bug-in-the-linux-kernel/" {
-		t.Errorf("Expected URL to be '%s', got '%s'", "https://engineering.skroutz.gr/blog/uncovering-a-24-year-old-bug-in-the-linux-kernel/", item.Url)
-	}
-}
-
-func TestFetchOneBroken(t *testing.T) {
-	const newsID = int64(33214439)
-
-	expected := `some-response`
-	fetcher := Fetcher{Settings: Configuration{ApiBaseUrl: ""}}
-
-	httpmock.Activate()
-
-	defer httpmock.DeactivateAndReset()
-
-	httpmock.RegisterResponder("GET", fetcher.Settings.ApiBaseUrl+"/item/33214439.json",
-		httpmock.NewStringResponder(200, expected))
-
-	item, err := fetcher.fetchOne(newsID)
-
-	if err == nil {
-		t.Error("Expected to fail a wrong response parsing")
-	}
-
-	if httpmock.GetTotalCallCount() < 1 {
-		t.Errorf("Expected a request while prefetching news items")
-	}
-
-	if item.Id != 0 {
-		t.Errorf("Expected ID to be %d, got %d", 0, item.Id)
+	if err := fetcher.prepareFilters(); err == nil {
+		t.Errorf("Expected an empty pattern to fail")
 	}
 }
 
@@ -297,7 +239,9 @@ func TestFilterItems(t *testing.T) {
 		Database:   Database{Driver: "sqlite3", Database: ":memory:"},
 	}}
 
-	fetcher.filters = fetcher.prepareFilters()
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
 
 	if err := fetcher.setUpRepository(); err != nil {
 		t.Errorf("Errof while initializing the repository, %v", err)
@@ -329,7 +273,8 @@ func TestFilterItems(t *testing.T) {
 			httpmock.NewStringResponder(200, resp))
 	}
 
-	prefetched, err := fetcher.prefetch()
+	source := newTestHackerNewsSource(t, &fetcher.Settings)
+	prefetched, err := source.Prefetch()
 
 	if err != nil {
 		t.Errorf("Error while prefetching news items, %v", err)
@@ -343,7 +288,7 @@ func TestFilterItems(t *testing.T) {
 		t.Errorf("Expected %s prefetched items, got %v", expectedPrefetched, *prefetched)
 	}
 
-	unfiltered, filtered, err := fetcher.filter(prefetched)
+	unfiltered, filtered, err := fetcher.filter(source, prefetched)
 
 	if err != nil {
 		t.Errorf("Error while filtering news items, %v", err)
@@ -362,6 +307,10 @@ func TestFilterItems(t *testing.T) {
 	if (*filtered)[0].id != 33215770 {
 		t.Errorf("Expected a news item with ID %d, got %d", 33215770, (*filtered)[0].id)
 	}
+
+	if filters := (*filtered)[0].filters; len(filters) != 1 || filters[0] != "Test filter" {
+		t.Errorf("Expected the item to carry the matched filter's title, got %v", filters)
+	}
 }
 
 func TestBlacklistedDomains(t *testing.T) {
@@ -416,7 +365,9 @@ func TestBlacklistedDomains(t *testing.T) {
 		Database:           Database{Driver: "sqlite3", Database: ":memory:"},
 	}}
 
-	fetcher.filters = fetcher.prepareFilters()
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
 
 	if err := fetcher.setUpRepository(); err != nil {
 		t.Errorf("Errof while initializing the repository, %v", err)
@@ -435,7 +386,8 @@ func TestBlacklistedDomains(t *testing.T) {
 			httpmock.NewStringResponder(200, resp))
 	}
 
-	prefetched, err := fetcher.prefetch()
+	source := newTestHackerNewsSource(t, &fetcher.Settings)
+	prefetched, err := source.Prefetch()
 
 	if err != nil {
 		t.Errorf("Error while prefetching news items, %v", err)
@@ -449,7 +401,7 @@ func TestBlacklistedDomains(t *testing.T) {
 		t.Errorf("Expected %s prefetched items, got %v", expectedPrefetched, *prefetched)
 	}
 
-	unfiltered, filtered, err := fetcher.filter(prefetched)
+	unfiltered, filtered, err := fetcher.filter(source, prefetched)
 
 	if err != nil {
 		t.Errorf("Error while filtering news items, %v", err)
@@ -523,7 +475,9 @@ func TestRun(t *testing.T) {
 		Database:           Database{Driver: "sqlite3", Database: ":memory:"},
 	}}
 
-	fetcher.filters = fetcher.prepareFilters()
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
 
 	if err := fetcher.setUpRepository(); err != nil {
 		t.Errorf("Errof while initializing the repository, %v", err)
@@ -556,3 +510,519 @@ func TestRun(t *testing.T) {
 		t.Errorf("Should be 1 news item in the digest, got %d", results.NewItems)
 	}
 }
+
+func TestFetchAllKeepsOrder(t *testing.T) {
+	ids := []int64{5, 3, 9, 1, 7, 2}
+	fetcher := Fetcher{Settings: Configuration{ApiBaseUrl: "", Fetch: FetchConfig{Concurrency: 3}}}
+
+	httpmock.Activate()
+
+	defer httpmock.DeactivateAndReset()
+
+	for _, id := range ids {
+		httpmock.RegisterResponder("GET", fmt.Sprintf(fetcher.Settings.ApiBaseUrl+"/item/%d.json", id),
+			httpmock.NewStringResponder(200, fmt.Sprintf(`{"id": %d, "title": "Title %d", "url": "http://host/%d"}`,
+				id, id, id)))
+	}
+
+	// An item that fails to decode must not break the order of the rest
+	httpmock.RegisterResponder("GET", fetcher.Settings.ApiBaseUrl+"/item/9.json",
+		httpmock.NewStringResponder(200, "broken"))
+
+	results := fetcher.fetchAll(newTestHackerNewsSource(t, &fetcher.Settings), ids)
+
+	if len(results) != len(ids) {
+		t.Fatalf("Expected %d results, got %d", len(ids), len(results))
+	}
+
+	if httpmock.GetTotalCallCount() != len(ids) {
+		t.Errorf("Expected %d requests, got %d", len(ids), httpmock.GetTotalCallCount())
+	}
+
+	for idx, id := range ids {
+		if id == 9 {
+			if results[idx].err == nil {
+				t.Errorf("Expected an error for the broken item at position %d", idx)
+			}
+
+			continue
+		}
+
+		if results[idx].err != nil {
+			t.Errorf("Unexpected error for item %d, %v", id, results[idx].err)
+		}
+
+		if results[idx].item.Id != id {
+			t.Errorf("Expected item %d at position %d, got %d", id, idx, results[idx].item.Id)
+		}
+	}
+}
+
+func TestThresholds(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		MinScore: 10,
+		Filters: []FilterItem{
+			{Title: "Global", Value: "title"},
+			{Title: "Popular", Value: "popular", MinScore: 100, MinComments: 5, MaxAgeHours: 12},
+		},
+	}}
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
+
+	now := time.Now().Unix()
+	testCases := []struct {
+		name     string
+		item     JsonNewsItem
+		expected verdict
+	}{
+		{"qualified", JsonNewsItem{Title: "Some Title", Score: 15, Time: now}, verdictInclude},
+		{"low score", JsonNewsItem{Title: "Some Title", Score: 5, Time: now}, verdictDefer},
+		{"aged out", JsonNewsItem{Title: "Some Title", Score: 5, Time: now - 48*3600}, verdictExpire},
+		{"no filter hit", JsonNewsItem{Title: "Some News", Score: 500, Time: now}, verdictSkip},
+		{"filter limits", JsonNewsItem{Title: "Popular News", Score: 50, Descendants: 10, Time: now}, verdictDefer},
+		{"filter qualified", JsonNewsItem{Title: "Popular News", Score: 150, Descendants: 10, Time: now},
+			verdictInclude},
+		{"filter max age", JsonNewsItem{Title: "Popular News", Score: 150, Descendants: 10, Time: now - 13*3600},
+			verdictExpire},
+		{"any filter qualifies", JsonNewsItem{Title: "Popular Title", Score: 50, Time: now}, verdictInclude},
+	}
+
+	for _, testCase := range testCases {
+		if result := fetcher.evaluate(&testCase.item); result != testCase.expected {
+			t.Errorf("%s: expected verdict %d, got %d", testCase.name, testCase.expected, result)
+		}
+	}
+}
+
+func TestDeferredItemsArePending(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		ApiBaseUrl: "",
+		MinScore:   100,
+		Filters:    []FilterItem{{Title: "Test filter", Value: "title"}},
+		Database:   Database{Driver: "sqlite3", Database: ":memory:"},
+	}}
+
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
+
+	if err := fetcher.setUpRepository(); err != nil {
+		t.Fatalf("Error while initializing the repository, %v", err)
+	}
+
+	defer fetcher.repository.Close()
+
+	httpmock.Activate()
+
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", fetcher.Settings.ApiBaseUrl+"/item/1.json",
+		httpmock.NewStringResponder(200, fmt.Sprintf(
+			`{"id": 1, "score": 5, "time": %d, "title": "Some Title", "url": "http://host/1"}`, time.Now().Unix())))
+
+	source := newTestHackerNewsSource(t, &fetcher.Settings)
+	unfiltered, filtered, err := fetcher.filter(source, &[]int64{1})
+
+	if err != nil {
+		t.Fatalf("Error while filtering news items, %v", err)
+	}
+
+	if len(*unfiltered) != 0 || len(*filtered) != 0 {
+		t.Errorf("A deferred item must be neither marked as seen nor sent, got %d and %d",
+			len(*unfiltered), len(*filtered))
+	}
+
+	if len(fetcher.deferred) != 1 {
+		t.Fatalf("Expected 1 deferred item, got %d", len(fetcher.deferred))
+	}
+
+	if err := fetcher.repository.DeferItems(&fetcher.deferred); err != nil {
+		t.Fatalf("Could not store the pending items, %v", err)
+	}
+
+	// The item qualifies on a later check and moves to delivered
+	fetcher.deferred = nil
+	fetcher.repository.db.MustExec("UPDATE news_items SET next_check_at = 0")
+	httpmock.RegisterResponder("GET", fetcher.Settings.ApiBaseUrl+"/item/1.json",
+		httpmock.NewStringResponder(200, fmt.Sprintf(
+			`{"id": 1, "score": 150, "time": %d, "title": "Some Title", "url": "http://host/1"}`, time.Now().Unix())))
+
+	unfiltered, filtered, err = fetcher.filter(source, &[]int64{})
+
+	if err != nil {
+		t.Fatalf("Error while filtering news items, %v", err)
+	}
+
+	if len(*filtered) != 1 {
+		t.Fatalf("Expected the pending item to be delivered, got %v", *filtered)
+	}
+
+	fetcher.fetched = *unfiltered
+
+	if _, err := fetcher.deliver(*filtered); err != nil {
+		t.Fatalf("Could not deliver the digest, %v", err)
+	}
+
+	var status string
+	if err := fetcher.repository.db.Get(&status, "SELECT status FROM news_items WHERE id = 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if status != StatusDelivered {
+		t.Errorf("Expected the item to be %s, got %s", StatusDelivered, status)
+	}
+}
+
+// A source some of whose items cannot be fetched
+type flakySource struct {
+	staticSource
+	missing []int64
+}
+
+func (s *flakySource) Prefetch() (*[]int64, error) {
+	ids, _ := s.staticSource.Prefetch()
+	*ids = append(*ids, s.missing...)
+
+	return ids, nil
+}
+
+func TestFailedItemsAreRetried(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		Filters:  []FilterItem{{Title: "Test filter", Value: "title"}},
+		Database: Database{Driver: "sqlite3", Database: ":memory:"},
+	}}
+
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
+
+	if err := fetcher.setUpRepository(); err != nil {
+		t.Fatalf("Error while initializing the repository, %v", err)
+	}
+
+	defer fetcher.repository.Close()
+
+	source := &flakySource{
+		staticSource: staticSource{items: []JsonNewsItem{{Id: 1, Title: "Some Title", Url: "http://host/1"}}},
+		missing:      []int64{2},
+	}
+
+	digest, err := fetcher.runSource(source)
+	if err != nil {
+		t.Fatalf("Error while running the source, %v", err)
+	}
+
+	if len(digest) != 1 || len(fetcher.failed) != 1 || fetcher.failed[0].id != 2 {
+		t.Fatalf("Expected 1 item sent and 1 failed, got %v and %v", digest, fetcher.failed)
+	}
+
+	if err := fetcher.store(); err != nil {
+		t.Fatalf("Error while storing the items, %v", err)
+	}
+
+	var stored []int64
+	if err := fetcher.repository.db.Select(&stored, "SELECT id FROM news_items"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stored) != 1 || stored[0] != 1 {
+		t.Errorf("A failed item must not be stored as seen, got %v", stored)
+	}
+
+	// The item can be fetched on the next run and is not retried anymore
+	source.items = append(source.items, JsonNewsItem{Id: 2, Title: "Other Title", Url: "http://host/2"})
+	source.missing = nil
+	fetcher.fetched, fetcher.failed = nil, nil
+
+	if digest, err = fetcher.runSource(source); err != nil {
+		t.Fatalf("Error while running the source, %v", err)
+	}
+
+	if len(digest) != 1 || digest[0].id != 2 || len(fetcher.failed) != 0 {
+		t.Errorf("Expected the failed item to be retried, got %v", digest)
+	}
+
+	if err := fetcher.store(); err != nil {
+		t.Fatalf("Error while storing the items, %v", err)
+	}
+
+	var retries int
+	if err := fetcher.repository.db.Get(&retries, "SELECT COUNT(*) FROM fetch_retries"); err != nil {
+		t.Fatal(err)
+	}
+
+	if retries != 0 {
+		t.Errorf("Expected no items left to retry, got %d", retries)
+	}
+}
+
+// A source that has nothing listed anymore
+type unlistedSource struct {
+	staticSource
+}
+
+func (s *unlistedSource) FetchOne(id int64) (JsonNewsItem, error) {
+	return JsonNewsItem{}, fmt.Errorf("item %d: %w", id, ErrNotListed)
+}
+
+func TestUnlistedItemsAreGivenUp(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		Filters:  []FilterItem{{Title: "Test filter", Value: "title"}},
+		Database: Database{Driver: "sqlite3", Database: ":memory:"},
+	}}
+
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
+
+	if err := fetcher.setUpRepository(); err != nil {
+		t.Fatalf("Error while initializing the repository, %v", err)
+	}
+
+	defer fetcher.repository.Close()
+
+	source := &unlistedSource{}
+
+	err := fetcher.repository.DeferItems(&[]DigestItem{{id: 1, newsTitle: "Some Title", newsUrl: "http://host/1",
+		createdAt: time.Now().Unix(), source: source.Name()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fetcher.repository.db.MustExec("UPDATE news_items SET next_check_at = 0")
+
+	failures := &[]fetchFailure{{id: 2, err: fmt.Errorf("connection reset"), source: source.Name()}}
+	if err := fetcher.repository.RecordFailures(failures); err != nil {
+		t.Fatal(err)
+	}
+
+	_, filtered, err := fetcher.filter(source, &[]int64{})
+	if err != nil {
+		t.Fatalf("Error while filtering news items, %v", err)
+	}
+
+	if len(*filtered) != 0 || len(fetcher.failed) != 0 || len(fetcher.unlisted) != 2 {
+		t.Fatalf("Expected the 2 items to be unlisted, not failed, got %v", fetcher.failed)
+	}
+
+	if err := fetcher.store(); err != nil {
+		t.Fatalf("Error while storing the items, %v", err)
+	}
+
+	var status string
+	if err := fetcher.repository.db.Get(&status, "SELECT status FROM news_items WHERE id = 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if status != StatusExpired {
+		t.Errorf("Expected the unlisted pending item to expire, got %s", status)
+	}
+
+	if ids, _ := fetcher.repository.GetIDsToPull(source.Name(), &[]int64{}); len(ids) != 0 {
+		t.Errorf("Expected the unlisted items not to be pulled again, got %v", ids)
+	}
+}
+
+func TestTextPostsAreFiltered(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		ApiBaseUrl: "",
+		Filters:    []FilterItem{{Title: "Ask HN", Value: "ask hn"}},
+		Database:   Database{Driver: "sqlite3", Database: ":memory:"},
+	}}
+
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
+
+	if err := fetcher.setUpRepository(); err != nil {
+		t.Fatalf("Error while initializing the repository, %v", err)
+	}
+
+	defer fetcher.repository.Close()
+
+	httpmock.Activate()
+
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", fetcher.Settings.ApiBaseUrl+"/item/1.json",
+		httpmock.NewStringResponder(200, `{"id": 1, "title": "Ask HN: Who is hiring?", "text": "Some text"}`))
+	httpmock.RegisterResponder("GET", fetcher.Settings.ApiBaseUrl+"/item/2.json",
+		httpmock.NewStringResponder(200, `{"id": 2, "deleted": true}`))
+
+	source := newTestHackerNewsSource(t, &fetcher.Settings)
+	unfiltered, filtered, err := fetcher.filter(source, &[]int64{1, 2})
+
+	if err != nil {
+		t.Fatalf("Error while filtering news items, %v", err)
+	}
+
+	if len(*unfiltered) != 2 || (*unfiltered)[1].newsTitle != "-" {
+		t.Errorf("Expected the deleted item to be recorded as seen, got %v", *unfiltered)
+	}
+
+	if len(*filtered) != 1 {
+		t.Fatalf("Expected 1 digest item, got %d", len(*filtered))
+	}
+
+	item := (*filtered)[0]
+
+	if item.newsTitle != "Ask HN: Who is hiring?" || item.newsText != "Some text" ||
+		item.newsUrl != "https://news.ycombinator.com/item?id=1" {
+		t.Errorf("Expected the text post to keep its title and text, got %v", item)
+	}
+
+	if item.discussionLabel() != "" {
+		t.Errorf("A text post's link is its discussion, got %s", item.discussionLabel())
+	}
+}
+
+func TestWhitelistedDomains(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		Filters:            []FilterItem{{Title: "Test filter", Value: "title"}},
+		BlacklistedDomains: []string{"medium.com", "trusted.org"},
+		WhitelistedDomains: []string{"engineering.medium.com", "trusted.org"},
+		Database:           Database{Driver: "sqlite3", Database: ":memory:"},
+	}}
+
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
+
+	if err := fetcher.setUpRepository(); err != nil {
+		t.Fatalf("Error while initializing the repository, %v", err)
+	}
+
+	defer fetcher.repository.Close()
+
+	source := &staticSource{items: []JsonNewsItem{
+		{Id: 1, Title: "Some Title", Url: "https://blog.medium.com/1"},
+		{Id: 2, Title: "Some News", Url: "https://engineering.medium.com/2"},
+		{Id: 3, Title: "Other News", Url: "https://www.trusted.org/3"},
+		{Id: 4, Title: "Other News", Url: "https://host/4"},
+	}}
+
+	prefetched, _ := source.Prefetch()
+
+	_, filtered, err := fetcher.filter(source, prefetched)
+	if err != nil {
+		t.Fatalf("Error while filtering news items, %v", err)
+	}
+
+	// Whitelisted domains get in whatever the title filters and the blacklist say
+	if len(*filtered) != 2 || (*filtered)[0].id != 2 || (*filtered)[1].id != 3 {
+		t.Errorf("Expected the whitelisted items 2 and 3 in the digest, got %v", *filtered)
+	}
+
+	// The reverse mode skips the whitelist, so a whitelisted item is only in its digest if no filter matches it
+	fetcher.Reverse = true
+	fetcher.blacklist = nil
+
+	matching := JsonNewsItem{Title: "Some Title", Url: "https://trusted.org/5", Time: time.Now().Unix()}
+	if verdict := fetcher.evaluate(&matching); verdict != verdictSkip {
+		t.Errorf("Expected the whitelisted item matching a filter to be skipped in the reverse mode, got %v", verdict)
+	}
+
+	other := JsonNewsItem{Title: "Other News", Url: "https://trusted.org/6", Time: time.Now().Unix()}
+	if verdict := fetcher.evaluate(&other); verdict != verdictInclude {
+		t.Errorf("Expected the whitelisted item matching no filter to be included in the reverse mode, got %v", verdict)
+	}
+}
+
+func TestRunSkipsFailingSources(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		Filters:  []FilterItem{{Title: "Test filter", Value: "some"}},
+		Database: Database{Driver: "sqlite3", Database: ":memory:"},
+		Sources: []SourceConfig{
+			{Type: FeedSourceType, Name: "broken", Url: "http://some-host/broken.xml"},
+			{Type: FeedSourceType, Name: "blog", Url: "http://some-host/feed.xml"},
+		},
+	}}
+
+	httpmock.Activate()
+
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://some-host/broken.xml", httpmock.NewStringResponder(404, ""))
+	httpmock.RegisterResponder("GET", "http://some-host/feed.xml", httpmock.NewStringResponder(200, testRSSFeed))
+
+	results, err := fetcher.Run()
+	if err != nil {
+		t.Fatalf("A failing source should not fail the run, %v", err)
+	}
+
+	if results.NewItems != 2 {
+		t.Errorf("Expected the other source's 2 items delivered, got %d", results.NewItems)
+	}
+}
+
+func TestRepostsAreSkipped(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		Filters:  []FilterItem{{Title: "Test filter", Value: "article"}},
+		Database: Database{Driver: "sqlite3", Database: ":memory:"},
+	}}
+
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
+
+	if err := fetcher.setUpRepository(); err != nil {
+		t.Fatalf("Error while initializing the repository, %v", err)
+	}
+
+	defer fetcher.repository.Close()
+
+	err := fetcher.repository.UpdateItems(&[]DigestItem{{id: 1, newsTitle: "Old article", newsUrl: "https://old.com/a",
+		canonicalUrl: "old.com/a", createdAt: time.Now().Unix(), status: StatusDelivered}})
+	if err != nil {
+		t.Fatalf("Could not store the delivered item, %v", err)
+	}
+
+	source := &staticSource{items: []JsonNewsItem{
+		{Id: 2, Title: "Some article", Url: "https://example.com/a"},
+		{Id: 3, Title: "Same article", Url: "http://m.example.com/a/?utm_source=hn"},
+		{Id: 4, Title: "Old article again", Url: "http://www.old.com/a#comments"},
+		{Id: 5, Title: "Another article", Url: "https://example.com/b"},
+	}}
+
+	digest, err := fetcher.runSource(source)
+	if err != nil {
+		t.Fatalf("Error while running the source, %v", err)
+	}
+
+	if len(digest) != 2 || digest[0].id != 2 || digest[1].id != 5 {
+		t.Fatalf("Expected the reposts to be skipped, got %v", digest)
+	}
+
+	// Nothing is stored before the digest is sent, so a repost from another source of the run
+	// is told by the run's digest
+	other := &staticSource{items: []JsonNewsItem{{Id: 6, Title: "Same article", Url: "https://example.com/b/"}}}
+
+	if digest, err = fetcher.runSource(other); err != nil || len(digest) != 0 {
+		t.Fatalf("Expected the repost from another source to be skipped, got %v, %v", digest, err)
+	}
+
+	if err := fetcher.store(); err != nil {
+		t.Fatalf("Error while storing the items, %v", err)
+	}
+
+	var seen []int64
+	if err := fetcher.repository.db.Select(&seen, "SELECT id FROM news_items WHERE status = ? ORDER BY id",
+		StatusSeen); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(seen) != 5 || seen[0] != 2 || seen[4] != 6 {
+		t.Errorf("Expected the items to be recorded as seen until the digest is sent, got %v", seen)
+	}
+
+	fetcher.Settings.Dedup.WindowDays = ptr[uint](1)
+	if repost, _ := fetcher.isRepost(&DigestItem{canonicalUrl: "old.com/a"}); !repost {
+		t.Errorf("Expected the article delivered today to be within the window")
+	}
+
+	fetcher.Settings.Dedup.WindowDays = ptr[uint](0)
+	if repost, _ := fetcher.isRepost(&DigestItem{canonicalUrl: "old.com/a"}); repost {
+		t.Errorf("Expected no reposts with the window of 0 days")
+	}
+}
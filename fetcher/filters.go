@@ -0,0 +1,184 @@
+package fetcher
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// Kinds of the filter parts reported in the errors and explanations
+const (
+	KindPattern        = "pattern"
+	KindExpression     = "expression"
+	KindExcludePattern = "exclude pattern"
+	KindField          = "field"
+)
+
+// Field the filter patterns are matched against, unless a filter sets its "Fields"
+const DefaultFilterField = "title"
+
+// A filter with its patterns compiled once, so that they are not recompiled for every item
+type compiledFilter struct {
+	FilterItem
+	fields     []string
+	patterns   []filterPattern
+	excludes   []filterPattern
+	expression filterExpression
+}
+
+// A compiled pattern of a filter's comma-separated value, or of its exclude list
+type filterPattern struct {
+	value string
+	regex *regexp.Regexp
+}
+
+// FilterMatch Why a filter matched a news item, and why the match was vetoed, if it was
+type FilterMatch struct {
+	Title string
+	// The pattern or expression that matched, e.g. `pattern "\bapi\b"`
+	Matched string
+	// The exclude pattern that vetoed the match, if any
+	Excluded string
+}
+
+// Whether the match stands, i.e. it was not vetoed by an exclude pattern
+func (match *FilterMatch) Hit() bool {
+	return match.Excluded == ""
+}
+
+func (match *FilterMatch) String() string {
+	if !match.Hit() {
+		return fmt.Sprintf("%s: %s matched, excluded by %s", match.Title, match.Matched, match.Excluded)
+	}
+
+	return fmt.Sprintf("%s: %s matched", match.Title, match.Matched)
+}
+
+// The first of the patterns that matches any of the item's fields, and the field it matched
+func firstMatch(patterns []filterPattern, item *JsonNewsItem, fields []string) (*filterPattern, string, bool) {
+	for idx := range patterns {
+		for _, field := range fields {
+			if patterns[idx].regex.MatchString(itemField(item, field)) {
+				return &patterns[idx], field, true
+			}
+		}
+	}
+
+	return nil, "", false
+}
+
+// How a matched field is shown in the explanations; the title is the default one, so it is not shown
+func fieldLabel(field string) string {
+	if field == DefaultFilterField {
+		return ""
+	}
+
+	return " in " + field
+}
+
+// Explain whether the filter's patterns match the item's fields, or its expression matches the
+// item, and whether an exclude pattern vetoes the match. False if the filter does not match at all.
+func (filter *compiledFilter) explain(item *JsonNewsItem) (FilterMatch, bool) {
+	result := FilterMatch{Title: filter.Title}
+
+	if pattern, field, hit := firstMatch(filter.patterns, item, filter.fields); hit {
+		result.Matched = fmt.Sprintf("%s \"%s\"%s", KindPattern, pattern.value, fieldLabel(field))
+	} else if filter.expression != nil && filter.expression.eval(item) {
+		result.Matched = fmt.Sprintf("%s \"%s\"", KindExpression, filter.Expression)
+	} else {
+		return result, false
+	}
+
+	if exclude, field, hit := firstMatch(filter.excludes, item, filter.fields); hit {
+		result.Excluded = fmt.Sprintf("%s \"%s\"%s", KindExcludePattern, exclude.value, fieldLabel(field))
+	}
+
+	return result, true
+}
+
+// Whether the filter matches the item and no exclude pattern vetoes it
+func (filter *compiledFilter) matches(item *JsonNewsItem) bool {
+	match, hit := filter.explain(item)
+
+	return hit && match.Hit()
+}
+
+// Compile the case-insensitive patterns. An empty pattern, e.g. after a trailing comma,
+// would match every item, so it is an error too.
+func compilePatterns(patterns []string, filterErr *FilterError) ([]filterPattern, error) {
+	compiled := make([]filterPattern, 0, len(patterns))
+
+	for patternIdx, pattern := range patterns {
+		filterErr.Pattern, filterErr.PatternPosition = pattern, patternIdx+1
+
+		if pattern == "" {
+			filterErr.Err = errors.New("empty pattern")
+			return nil, filterErr
+		}
+
+		regex, err := regexp.Compile(RegexCaseInsensitive + pattern)
+		if err != nil {
+			filterErr.Err = err
+			return nil, filterErr
+		}
+
+		compiled = append(compiled, filterPattern{value: pattern, regex: regex})
+	}
+
+	return compiled, nil
+}
+
+// Compile the patterns and parse the expressions of all the filters. A pattern that does not
+// compile is reported with the filter's title and position, and the pattern's position in the
+// filter's value; an expression with a syntax error, with the position of the error in it.
+func compileFilters(filters []FilterItem) ([]compiledFilter, error) {
+	var err error
+
+	compiled := make([]compiledFilter, 0, len(filters))
+
+	for filterIdx, filter := range filters {
+		result := compiledFilter{FilterItem: filter, fields: filter.Fields}
+
+		if len(result.fields) == 0 {
+			result.fields = []string{DefaultFilterField}
+		}
+
+		for fieldIdx, field := range result.fields {
+			if !slices.Contains(TextFields, field) {
+				return nil, &FilterError{Title: filter.Title, Position: filterIdx + 1, Kind: KindField, Pattern: field,
+					PatternPosition: fieldIdx + 1, Err: fmt.Errorf("unknown field, expected one of %s",
+						strings.Join(TextFields, ", "))}
+			}
+		}
+
+		if filter.Expression != "" {
+			result.expression, err = parseExpression(filter.Expression)
+			if err != nil {
+				return nil, &FilterError{Title: filter.Title, Position: filterIdx + 1, Kind: KindExpression,
+					Pattern: filter.Expression, Err: err}
+			}
+		}
+
+		// A filter with an expression does not need a value
+		if filter.Value != "" || filter.Expression == "" {
+			result.patterns, err = compilePatterns(strings.Split(filter.Value, ","),
+				&FilterError{Title: filter.Title, Position: filterIdx + 1, Kind: KindPattern})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		result.excludes, err = compilePatterns(filter.Exclude,
+			&FilterError{Title: filter.Title, Position: filterIdx + 1, Kind: KindExcludePattern})
+		if err != nil {
+			return nil, err
+		}
+
+		compiled = append(compiled, result)
+	}
+
+	return compiled, nil
+}
@@ -0,0 +1,136 @@
+package fetcher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExcludePatterns(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		Filters: []FilterItem{
+			{Title: "API", Value: "api", Exclude: []string{"rapid"}},
+			{Title: "Covid", Value: "covid", Exclude: []string{"stocks?", "market"}},
+			{Title: "Speed", Value: "rapid"},
+		},
+	}}
+
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
+
+	testCases := []struct {
+		title    string
+		expected []string
+	}{
+		{title: "A new API for payments", expected: []string{"API"}},
+		{title: "Rapid prototyping", expected: []string{"Speed"}},
+		{title: "Covid vaccine trial results", expected: []string{"Covid"}},
+		{title: "Covid drags the stock market down", expected: nil},
+	}
+
+	for _, testCase := range testCases {
+		matched := filterTitles(fetcher.matchingFilters(&JsonNewsItem{Title: testCase.title}))
+
+		if strings.Join(matched, ",") != strings.Join(testCase.expected, ",") {
+			t.Errorf("Expected %q to match %v, got %v", testCase.title, testCase.expected, matched)
+		}
+	}
+
+	// The vetoed match is reported with its reason
+	matches := fetcher.explainFilters(&JsonNewsItem{Title: "Rapid API prototyping"})
+
+	if len(matches) != 2 || matches[0].Hit() || !matches[1].Hit() {
+		t.Fatalf("Expected a vetoed API match and a Speed match, got %v", matches)
+	}
+
+	if reason := matches[0].String(); reason != `API: pattern "api" matched, excluded by exclude pattern "rapid"` {
+		t.Errorf("Unexpected reason %s", reason)
+	}
+}
+
+func TestExcludePatternsReverse(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		Filters: []FilterItem{{Title: "Covid", Value: "covid", Exclude: []string{"market"}}},
+	}, Reverse: true}
+
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
+
+	// A vetoed match does not count as a hit, so the item gets into the reversed digest
+	if !fetcher.filterItem(&JsonNewsItem{Title: "Covid and the market"}) {
+		t.Errorf("Expected a vetoed match not to be a hit in the reverse mode")
+	}
+
+	if fetcher.filterItem(&JsonNewsItem{Title: "Covid vaccines"}) {
+		t.Errorf("Expected a match to be a hit in the reverse mode")
+	}
+}
+
+func TestInvalidExcludePattern(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		Filters: []FilterItem{{Title: "API", Value: "api", Exclude: []string{"rapid", "(broken"}}},
+	}}
+
+	err := fetcher.prepareFilters()
+	if err == nil || !strings.Contains(err.Error(), `filter "API" (#1), exclude pattern "(broken" (#2)`) {
+		t.Errorf("Expected the broken exclude pattern to be reported, got %v", err)
+	}
+}
+
+func TestFilterFields(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		Filters: []FilterItem{
+			{Title: "Rust repos", Value: `github\.com/.*/rust`, Fields: []string{"url"}},
+			{Title: "Papers", Value: `^arxiv\.org$`, Fields: []string{"domain"}},
+			{Title: "Ask HN", Value: "kubernetes", Fields: []string{"title", "text"}, Exclude: []string{"hiring"}},
+			{Title: "People", Value: "^pg$", Fields: []string{"by"}},
+			{Title: "Default", Value: "arxiv"},
+		},
+	}}
+
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
+
+	testCases := []struct {
+		item     JsonNewsItem
+		expected []string
+	}{
+		{item: JsonNewsItem{Title: "A new crate", Url: "https://github.com/someone/rust-crate"},
+			expected: []string{"Rust repos"}},
+		{item: JsonNewsItem{Title: "Attention is all you need", Url: "https://arxiv.org/abs/1706.03762"},
+			expected: []string{"Papers"}},
+		{item: JsonNewsItem{Title: "Ask HN: Is it worth it?", Text: "Running Kubernetes at home"},
+			expected: []string{"Ask HN"}},
+		{item: JsonNewsItem{Title: "Ask HN: Who is hiring?", Text: "Kubernetes engineers"}, expected: nil},
+		{item: JsonNewsItem{Title: "Some essay", By: "pg"}, expected: []string{"People"}},
+		{item: JsonNewsItem{Title: "Arxiv is down", Url: "https://status.arxiv.org/"},
+			expected: []string{"Default"}},
+	}
+
+	for _, testCase := range testCases {
+		matched := filterTitles(fetcher.matchingFilters(&testCase.item))
+
+		if strings.Join(matched, ",") != strings.Join(testCase.expected, ",") {
+			t.Errorf("Expected %q to match %v, got %v", testCase.item.Title, testCase.expected, matched)
+		}
+	}
+
+	matches := fetcher.explainFilters(&JsonNewsItem{Title: "Ask HN: Who is hiring?", Text: "Kubernetes engineers"})
+	if len(matches) != 1 ||
+		matches[0].String() != `Ask HN: pattern "kubernetes" in text matched, excluded by exclude pattern "hiring"` {
+		t.Errorf("Unexpected explanation %v", matches)
+	}
+}
+
+func TestUnknownFilterField(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		Filters: []FilterItem{{Title: "Links", Value: "rust", Fields: []string{"url", "link"}}},
+	}}
+
+	err := fetcher.prepareFilters()
+	if err == nil || !strings.Contains(err.Error(), `filter "Links" (#1), field "link" (#2): unknown field`) {
+		t.Errorf("Expected the unknown field to be reported, got %v", err)
+	}
+}
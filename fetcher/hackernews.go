@@ -0,0 +1,108 @@
+package fetcher
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/slices"
+)
+
+const (
+	HackerNewsSourceType = "hackernews"
+	HackerNewsItemUrl    = "https://news.ycombinator.com/item?id=%d"
+)
+
+// HackerNewsSource Items of the HackerNews story lists, read from the Firebase API
+type HackerNewsSource struct {
+	name      string
+	baseUrl   string
+	lists     []string
+	itemLists map[int64][]string
+	client    *HttpClient
+}
+
+func newHackerNewsSource(settings *Configuration, config *SourceConfig, client *HttpClient) (Source, error) {
+	source := &HackerNewsSource{
+		name:    config.Name,
+		baseUrl: config.Url,
+		lists:   config.Lists,
+		client:  client,
+	}
+
+	if source.baseUrl == "" {
+		source.baseUrl = settings.ApiBaseUrl
+	}
+
+	if len(source.lists) == 0 {
+		source.lists = settings.Fetch.Lists
+	}
+
+	if len(source.lists) == 0 {
+		source.lists = []string{DefaultStoryList}
+	}
+
+	for _, list := range source.lists {
+		if !slices.Contains(StoryLists, list) {
+			return nil, fmt.Errorf("unknown story list %q, expected one of %v", list, StoryLists)
+		}
+	}
+
+	return source, nil
+}
+
+func (s *HackerNewsSource) Name() string {
+	return s.name
+}
+
+// Get one story list's IDs
+func (s *HackerNewsSource) prefetchList(list string) ([]int64, error) {
+	var result []int64
+
+	err := s.client.GetJSON(fmt.Sprintf("%s/%s.json", s.baseUrl, list), &result)
+
+	return result, err
+}
+
+// Prefetch Get the configured story lists' IDs merged and deduplicated. The IDs keep the ranking
+// of the first list they appear in, and the lists every ID came from are remembered.
+func (s *HackerNewsSource) Prefetch() (*[]int64, error) {
+	var result []int64
+
+	s.itemLists = make(map[int64][]string)
+
+	for _, list := range s.lists {
+		ids, err := s.prefetchList(list)
+		if err != nil {
+			return &result, err
+		}
+
+		for _, id := range ids {
+			if _, seen := s.itemLists[id]; !seen {
+				result = append(result, id)
+			}
+
+			s.itemLists[id] = append(s.itemLists[id], list)
+		}
+	}
+
+	return &result, nil
+}
+
+// FetchOne Fetch one news item as a JSON object
+func (s *HackerNewsSource) FetchOne(id int64) (JsonNewsItem, error) {
+	var result JsonNewsItem
+
+	if err := s.client.GetJSON(fmt.Sprintf("%s/item/%d.json", s.baseUrl, id), &result); err != nil {
+		return result, err
+	}
+
+	result.Source = s.name
+	result.Lists = s.itemLists[id]
+	result.Discussion = fmt.Sprintf(HackerNewsItemUrl, id)
+
+	// Ask HN, Show HN and other text posts link to their discussion
+	if result.Url == "" {
+		result.Url = result.Discussion
+	}
+
+	return result, nil
+}
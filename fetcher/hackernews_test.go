@@ -0,0 +1,214 @@
+package fetcher
+
+import (
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func newTestHackerNewsSource(t *testing.T, settings *Configuration) *HackerNewsSource {
+	client, err := newHttpClient(&settings.Http)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := newHackerNewsSource(settings, &SourceConfig{Type: HackerNewsSourceType, Name: HackerNewsSourceType},
+		client)
+	if err != nil {
+		t.Fatalf("Could not create the HackerNews source, %v", err)
+	}
+
+	hackerNews, _ := source.(*HackerNewsSource)
+
+	return hackerNews
+}
+
+func TestPrefetch(t *testing.T) {
+	const expected = "[33214439,33215770]"
+
+	source := newTestHackerNewsSource(t, &Configuration{ApiBaseUrl: ""})
+
+	httpmock.Activate()
+
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", source.baseUrl+"/topstories.json",
+		httpmock.NewStringResponder(200, expected))
+
+	value, _ := source.Prefetch()
+
+	if httpmock.GetTotalCallCount() < 1 {
+		t.Errorf("Expected a request while prefetching news items")
+	}
+
+	if len(*value) != 2 {
+		t.Errorf("Expected 2 prefetched items, got %d", len(*value))
+	}
+
+	if (*value)[0] != 33214439 || (*value)[1] != 33215770 {
+		t.Errorf("Expected %s prefetched items, got %v", expected, *value)
+	}
+}
+
+func TestFetchOne(t *testing.T) {
+	const newsID = int64(33214439)
+
+	expected := `{
+		"by": "endorphine",
+		"descendants": 1,
+		"id": 33214439,
+		"kids": [
+			33216431
+		],
+		"score": 289,
+		"time": 1665839339,
+		"title": "A 24-year-old bug in the Linux Kernel TCP stack (2021)",
+		"type": "story",
+		"url": "https://engineering.skroutz.gr/blog/uncovering-a-24-year-old-bug-in-the-linux-kernel/"
+	}`
+	source := newTestHackerNewsSource(t, &Configuration{ApiBaseUrl: ""})
+
+	httpmock.Activate()
+
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", source.baseUrl+"/item/33214439.json",
+		httpmock.NewStringResponder(200, expected))
+
+	item, err := source.FetchOne(newsID)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if httpmock.GetTotalCallCount() < 1 {
+		t.Errorf("Expected a request while prefetching news items")
+	}
+
+	if item.Id != newsID {
+		t.Errorf("Expected ID to be %d, got %d", newsID, item.Id)
+	}
+
+	if item.Title != "A 24-year-old bug in the Linux Kernel TCP stack (2021)" {
+		t.Errorf("Expected ID to be %s, got %s", "A 24-year-old bug in the Linux Kernel TCP stack (2021)", item.Title)
+	}
+
+	if item.Url != "https://engineering.skroutz.gr/blog/uncovering-a-24-year-old-bug-in-the-linux-kernel/" {
+		t.Errorf("Expected URL to be '%s', got '%s'",
+			"https://engineering.skroutz.gr/blog/uncovering-a-24-year-old-bug-in-the-linux-kernel/", item.Url)
+	}
+
+	if item.Score != 289 || item.By != "endorphine" || item.Descendants != 1 || item.Type != "story" {
+		t.Errorf("Unexpected item details: score %d, by %s, descendants %d, type %s",
+			item.Score, item.By, item.Descendants, item.Type)
+	}
+
+	if item.Discussion != "https://news.ycombinator.com/item?id=33214439" {
+		t.Errorf("Expected the discussion link to be set, got '%s'", item.Discussion)
+	}
+}
+
+func TestFetchOneTextPost(t *testing.T) {
+	source := newTestHackerNewsSource(t, &Configuration{ApiBaseUrl: ""})
+
+	httpmock.Activate()
+
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", source.baseUrl+"/item/121003.json",
+		httpmock.NewStringResponder(200, `{"by": "tel", "id": 121003, "score": 25, "time": 1203647620,
+			"title": "Ask HN: The Arc Effect", "text": "<i>or</i> HN: the Next Iteration", "type": "story"}`))
+
+	item, err := source.FetchOne(121003)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const discussion = "https://news.ycombinator.com/item?id=121003"
+
+	if item.Title != "Ask HN: The Arc Effect" || item.Text != "<i>or</i> HN: the Next Iteration" {
+		t.Errorf("Expected the title and text to be kept, got %q and %q", item.Title, item.Text)
+	}
+
+	if item.Url != discussion || item.Discussion != discussion {
+		t.Errorf("Expected a text post to link to %s, got %s and %s", discussion, item.Url, item.Discussion)
+	}
+}
+
+func TestFetchOneBroken(t *testing.T) {
+	const newsID = int64(33214439)
+
+	expected := `some-response`
+	source := newTestHackerNewsSource(t, &Configuration{ApiBaseUrl: ""})
+
+	httpmock.Activate()
+
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", source.baseUrl+"/item/33214439.json",
+		httpmock.NewStringResponder(200, expected))
+
+	item, err := source.FetchOne(newsID)
+
+	if err == nil {
+		t.Error("Expected to fail a wrong response parsing")
+	}
+
+	if httpmock.GetTotalCallCount() < 1 {
+		t.Errorf("Expected a request while prefetching news items")
+	}
+
+	if item.Id != 0 {
+		t.Errorf("Expected ID to be %d, got %d", 0, item.Id)
+	}
+}
+
+func TestPrefetchMultipleLists(t *testing.T) {
+	source := newTestHackerNewsSource(t, &Configuration{
+		ApiBaseUrl: "",
+		Fetch:      FetchConfig{Lists: []string{"beststories", "showstories"}},
+	})
+
+	httpmock.Activate()
+
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", source.baseUrl+"/beststories.json",
+		httpmock.NewStringResponder(200, "[3,1,2]"))
+	httpmock.RegisterResponder("GET", source.baseUrl+"/showstories.json",
+		httpmock.NewStringResponder(200, "[4,1]"))
+
+	value, err := source.Prefetch()
+
+	if err != nil {
+		t.Fatalf("Error while prefetching news items, %v", err)
+	}
+
+	expected := []int64{3, 1, 2, 4}
+
+	if len(*value) != len(expected) {
+		t.Fatalf("Expected %v prefetched items, got %v", expected, *value)
+	}
+
+	for idx, id := range expected {
+		if (*value)[idx] != id {
+			t.Errorf("Expected %v prefetched items, got %v", expected, *value)
+		}
+	}
+
+	if lists := source.itemLists[1]; len(lists) != 2 || lists[0] != "beststories" || lists[1] != "showstories" {
+		t.Errorf("Expected item 1 to come from both lists, got %v", lists)
+	}
+
+	if lists := source.itemLists[4]; len(lists) != 1 || lists[0] != "showstories" {
+		t.Errorf("Expected item 4 to come from showstories only, got %v", lists)
+	}
+}
+
+func TestUnknownStoryList(t *testing.T) {
+	settings := Configuration{Fetch: FetchConfig{Lists: []string{"hotstories"}}}
+
+	if _, err := newSources(&settings); err == nil {
+		t.Error("Expected an unknown story list to fail creating the source")
+	}
+}
@@ -0,0 +1,203 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultUserAgent                = "hackernews_digest_go (+https://github.com/utking/hackernews_digest_go)"
+	DefaultHttpTimeoutSeconds       = 30
+	DefaultHttpRetries              = 3
+	DefaultHttpBackoffMillis        = 500
+	DefaultHttpMaxBackoffMillis     = 30000
+	DefaultHttpMaxResponseMegabytes = 32
+)
+
+// HttpClient The HTTP client shared by all the sources. It times requests out, retries the failed
+// ones with a jittered exponential backoff, and keeps the request rate to every host under the limit.
+type HttpClient struct {
+	client     *http.Client
+	userAgent  string
+	retries    uint
+	backoff    time.Duration
+	maxBackoff time.Duration
+	interval   time.Duration
+	mu         sync.Mutex
+	nextSlot   map[string]time.Time
+}
+
+func newHttpClient(config *HttpConfig) (*HttpClient, error) {
+	client := &HttpClient{
+		// No transport set means http.DefaultTransport, looked up on every request
+		client:     &http.Client{Timeout: time.Duration(config.TimeoutSeconds) * time.Second},
+		userAgent:  config.UserAgent,
+		retries:    optional(config.Retries, DefaultHttpRetries),
+		backoff:    time.Duration(config.BackoffMilliseconds) * time.Millisecond,
+		maxBackoff: time.Duration(config.MaxBackoffMilliseconds) * time.Millisecond,
+		nextSlot:   make(map[string]time.Time),
+	}
+
+	if config.TimeoutSeconds == 0 {
+		client.client.Timeout = DefaultHttpTimeoutSeconds * time.Second
+	}
+
+	if client.userAgent == "" {
+		client.userAgent = DefaultUserAgent
+	}
+
+	if client.backoff == 0 {
+		client.backoff = DefaultHttpBackoffMillis * time.Millisecond
+	}
+
+	if client.maxBackoff == 0 {
+		client.maxBackoff = DefaultHttpMaxBackoffMillis * time.Millisecond
+	}
+
+	if config.RequestsPerSecond > 0 {
+		client.interval = time.Duration(float64(time.Second) / config.RequestsPerSecond)
+	}
+
+	if config.Proxy != "" {
+		proxyURL, err := url.Parse(config.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("wrong proxy URL %q: %w", config.Proxy, err)
+		}
+
+		transport, ok := http.DefaultTransport.(*http.Transport)
+		if !ok {
+			return nil, errors.New("the default HTTP transport can't be configured with a proxy")
+		}
+
+		transport = transport.Clone()
+		transport.Proxy = http.ProxyURL(proxyURL)
+		client.client.Transport = transport
+	}
+
+	return client, nil
+}
+
+// Wait for the host's next free request slot
+func (c *HttpClient) throttle(host string) {
+	if c.interval == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	slot := time.Now()
+	if next := c.nextSlot[host]; next.After(slot) {
+		slot = next
+	}
+	c.nextSlot[host] = slot.Add(c.interval)
+	c.mu.Unlock()
+
+	time.Sleep(time.Until(slot))
+}
+
+// Delay before the retry after the given number of attempts: exponential, with the
+// upper half randomized, so that the workers do not retry all at once
+func (c *HttpClient) retryDelay(attempt uint) time.Duration {
+	delay := c.backoff
+
+	for i := uint(1); i < attempt && delay < c.maxBackoff; i++ {
+		delay *= 2
+	}
+
+	delay = min(delay, c.maxBackoff)
+
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// Network errors and the overloaded or failing servers are worth another try, but not the
+// requests that can never succeed, e.g. of a malformed URL or with an unsupported scheme
+func retryable(err error) bool {
+	var (
+		fetchErr *FetchError
+		urlErr   *url.Error
+		opErr    *net.OpError
+		netErr   net.Error
+	)
+
+	if errors.As(err, &fetchErr) {
+		return fetchErr.StatusCode == http.StatusTooManyRequests ||
+			fetchErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	// Every failed request is a url.Error, which is a net.Error itself, so the error it wraps decides
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &opErr) {
+		return true
+	}
+
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// Send one GET request and read the whole response body
+func (c *HttpClient) getOnce(rawURL string) ([]byte, time.Duration, error) {
+	request, err := http.NewRequestWithContext(context.Background(), http.MethodGet, rawURL, http.NoBody)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	request.Header.Set("User-Agent", c.userAgent)
+	c.throttle(request.URL.Host)
+
+	resp, err := c.client.Do(request)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		retryAfter := time.Duration(0)
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+
+		return nil, retryAfter, &FetchError{Url: rawURL, StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, DefaultHttpMaxResponseMegabytes<<20))
+
+	return body, 0, err
+}
+
+// Get Request a URL, retrying on the network errors, 429 and 5xx responses
+func (c *HttpClient) Get(rawURL string) ([]byte, error) {
+	var attempt uint
+
+	for {
+		body, retryAfter, err := c.getOnce(rawURL)
+		if err == nil || !retryable(err) || attempt >= c.retries {
+			return body, err
+		}
+
+		// A Retry-After longer than the backoff limit is not waited out in full
+		attempt++
+		time.Sleep(max(min(retryAfter, c.maxBackoff), c.retryDelay(attempt)))
+	}
+}
+
+// GetJSON Request a JSON document and decode it into the target
+func (c *HttpClient) GetJSON(rawURL string, target any) error {
+	body, err := c.Get(rawURL)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, target)
+}
@@ -0,0 +1,221 @@
+package fetcher
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func newTestHttpClient(t *testing.T, config HttpConfig) *HttpClient {
+	client, err := newHttpClient(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func TestHttpClientRetries(t *testing.T) {
+	const testURL = "http://some-host/item.json"
+
+	client := newTestHttpClient(t, HttpConfig{Retries: ptr[uint](3), BackoffMilliseconds: 1, UserAgent: "test-agent"})
+	calls := 0
+
+	httpmock.Activate()
+
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", testURL, func(req *http.Request) (*http.Response, error) {
+		calls++
+
+		if req.Header.Get("User-Agent") != "test-agent" {
+			t.Errorf("Expected the configured User-Agent, got %s", req.Header.Get("User-Agent"))
+		}
+
+		switch calls {
+		case 1:
+			return httpmock.NewStringResponse(http.StatusServiceUnavailable, ""), nil
+		case 2:
+			return httpmock.NewStringResponse(http.StatusTooManyRequests, ""), nil
+		default:
+			return httpmock.NewStringResponse(http.StatusOK, `{"id": 1}`), nil
+		}
+	})
+
+	var item JsonNewsItem
+
+	if err := client.GetJSON(testURL, &item); err != nil {
+		t.Fatalf("Expected the request to succeed after the retries, %v", err)
+	}
+
+	if calls != 3 || item.Id != 1 {
+		t.Errorf("Expected 3 calls and item 1, got %d calls and item %d", calls, item.Id)
+	}
+}
+
+func TestHttpClientStatusErrors(t *testing.T) {
+	const testURL = "http://some-host/missing.json"
+
+	client := newTestHttpClient(t, HttpConfig{Retries: ptr[uint](2), BackoffMilliseconds: 1})
+
+	httpmock.Activate()
+
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", testURL, httpmock.NewStringResponder(http.StatusNotFound, "not found"))
+
+	_, err := client.Get(testURL)
+
+	var fetchErr *FetchError
+	if !errors.As(err, &fetchErr) || fetchErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected a FetchError with the status 404, got %v", err)
+	}
+
+	// Client errors are not retried
+	if httpmock.GetTotalCallCount() != 1 {
+		t.Errorf("Expected 1 call, got %d", httpmock.GetTotalCallCount())
+	}
+
+	httpmock.RegisterResponder("GET", testURL, httpmock.NewStringResponder(http.StatusBadGateway, ""))
+	httpmock.ZeroCallCounters()
+
+	if _, err := client.Get(testURL); !errors.As(err, &fetchErr) || fetchErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("Expected a FetchError with the status 502, got %v", err)
+	}
+
+	if httpmock.GetTotalCallCount() != 3 {
+		t.Errorf("Expected 1 call and 2 retries, got %d calls", httpmock.GetTotalCallCount())
+	}
+}
+
+func TestHttpClientNoRetries(t *testing.T) {
+	const testURL = "http://some-host/item.json"
+
+	client := newTestHttpClient(t, HttpConfig{Retries: ptr[uint](0)})
+
+	httpmock.Activate()
+
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", testURL, httpmock.NewStringResponder(http.StatusServiceUnavailable, ""))
+
+	if _, err := client.Get(testURL); err == nil {
+		t.Fatal("Expected the request to fail")
+	}
+
+	if httpmock.GetTotalCallCount() != 1 {
+		t.Errorf("Expected no retries, got %d calls", httpmock.GetTotalCallCount())
+	}
+}
+
+func TestHttpClientRetryAfter(t *testing.T) {
+	const testURL = "http://some-host/item.json"
+
+	client := newTestHttpClient(t, HttpConfig{Retries: ptr[uint](1), BackoffMilliseconds: 1,
+		MaxBackoffMilliseconds: 10})
+
+	httpmock.Activate()
+
+	defer httpmock.DeactivateAndReset()
+
+	response := httpmock.NewStringResponse(http.StatusTooManyRequests, "")
+	response.Header.Set("Retry-After", "3600")
+	httpmock.RegisterResponder("GET", testURL, httpmock.ResponderFromResponse(response))
+
+	started := time.Now()
+
+	if _, err := client.Get(testURL); err == nil {
+		t.Fatal("Expected the request to fail")
+	}
+
+	// The hour asked for is capped at the backoff limit
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("Expected the Retry-After to be capped, took %v", elapsed)
+	}
+}
+
+func TestHttpClientRetryable(t *testing.T) {
+	testCases := map[string]struct {
+		err      error
+		expected bool
+	}{
+		"server error": {&FetchError{StatusCode: http.StatusBadGateway}, true},
+		"not found":    {&FetchError{StatusCode: http.StatusNotFound}, false},
+		"reset":        {&url.Error{Op: "Get", Err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}}, true},
+		"closed":       {&url.Error{Op: "Get", Err: io.ErrUnexpectedEOF}, true},
+		"scheme":       {&url.Error{Op: "Get", Err: errors.New("unsupported protocol scheme \"gopher\"")}, false},
+	}
+
+	for name, testCase := range testCases {
+		if retryable(testCase.err) != testCase.expected {
+			t.Errorf("%s: expected retryable to be %v", name, testCase.expected)
+		}
+	}
+
+	// A malformed URL is reported as a url.Error too
+	_, err := newTestHttpClient(t, HttpConfig{}).Get("http://[::1")
+	if err == nil || retryable(err) {
+		t.Errorf("Expected a malformed URL not to be retried, got %v", err)
+	}
+}
+
+func TestHttpClientRateLimit(t *testing.T) {
+	client := newTestHttpClient(t, HttpConfig{RequestsPerSecond: 20})
+
+	httpmock.Activate()
+
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://some-host/1.json", httpmock.NewStringResponder(200, "{}"))
+	httpmock.RegisterResponder("GET", "http://other-host/1.json", httpmock.NewStringResponder(200, "{}"))
+
+	started := time.Now()
+
+	for range 3 {
+		if _, err := client.Get("http://some-host/1.json"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Two waits of 50ms between three requests to the same host
+	if elapsed := time.Since(started); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected the requests to be spread, took %v", elapsed)
+	}
+
+	started = time.Now()
+
+	// Another host has its own limit
+	if _, err := client.Get("http://other-host/1.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(started); elapsed > 40*time.Millisecond {
+		t.Errorf("Expected no wait for another host, took %v", elapsed)
+	}
+}
+
+func TestHttpClientBackoff(t *testing.T) {
+	client := newTestHttpClient(t, HttpConfig{BackoffMilliseconds: 100, MaxBackoffMilliseconds: 300})
+
+	for attempt, limit := range map[uint]time.Duration{1: 100, 2: 200, 3: 300, 10: 300} {
+		limit *= time.Millisecond
+
+		if delay := client.retryDelay(attempt); delay < limit/2 || delay > limit {
+			t.Errorf("Expected the delay after %d attempts to be within [%v, %v], got %v", attempt, limit/2, limit,
+				delay)
+		}
+	}
+}
+
+func TestHttpClientWrongProxy(t *testing.T) {
+	if _, err := newHttpClient(&HttpConfig{Proxy: "://proxy"}); err == nil {
+		t.Error("Expected a wrong proxy URL to fail")
+	}
+}
@@ -0,0 +1,148 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+const (
+	LobstersSourceType = "lobsters"
+	LobstersBaseUrl    = "https://lobste.rs"
+)
+
+// Story lists provided by the Lobsters JSON API
+var LobstersLists = []string{"hottest", "newest", "active"}
+
+type lobstersStory struct {
+	ShortId      string          `json:"short_id"`
+	Title        string          `json:"title"`
+	Url          string          `json:"url"`
+	CommentsUrl  string          `json:"comments_url"`
+	Description  string          `json:"description_plain"`
+	CreatedAt    string          `json:"created_at"`
+	Submitter    json.RawMessage `json:"submitter_user"`
+	Tags         []string        `json:"tags"`
+	Score        int64           `json:"score"`
+	CommentCount int64           `json:"comment_count"`
+}
+
+// LobstersSource Stories of the Lobsters lists, with their tags
+type LobstersSource struct {
+	name    string
+	baseUrl string
+	lists   []string
+	items   map[int64]JsonNewsItem
+	client  *HttpClient
+}
+
+func newLobstersSource(_ *Configuration, config *SourceConfig, client *HttpClient) (Source, error) {
+	source := &LobstersSource{name: config.Name, baseUrl: config.Url, lists: config.Lists, client: client}
+
+	if source.baseUrl == "" {
+		source.baseUrl = LobstersBaseUrl
+	}
+
+	if len(source.lists) == 0 {
+		source.lists = []string{"hottest"}
+	}
+
+	for _, list := range source.lists {
+		if !slices.Contains(LobstersLists, list) {
+			return nil, fmt.Errorf("unknown Lobsters list %q, expected one of %v", list, LobstersLists)
+		}
+	}
+
+	return source, nil
+}
+
+func (s *LobstersSource) Name() string {
+	return s.name
+}
+
+// Prefetch Get the configured lists merged and deduplicated
+func (s *LobstersSource) Prefetch() (*[]int64, error) {
+	var result []int64
+
+	s.items = make(map[int64]JsonNewsItem)
+
+	for _, list := range s.lists {
+		var stories []lobstersStory
+
+		if err := s.client.GetJSON(fmt.Sprintf("%s/%s.json", s.baseUrl, list), &stories); err != nil {
+			return &result, err
+		}
+
+		for _, story := range stories {
+			id := namespacedID(LobstersSourceType, story.ShortId)
+
+			if item, seen := s.items[id]; seen {
+				item.Lists = append(item.Lists, list)
+				s.items[id] = item
+
+				continue
+			}
+
+			s.items[id] = s.normalize(id, list, &story)
+			result = append(result, id)
+		}
+	}
+
+	return &result, nil
+}
+
+// FetchOne Return a story of the last prefetched lists
+func (s *LobstersSource) FetchOne(id int64) (JsonNewsItem, error) {
+	item, ok := s.items[id]
+	if !ok {
+		return JsonNewsItem{}, fmt.Errorf("item %d of the Lobsters lists: %w", id, ErrNotListed)
+	}
+
+	return item, nil
+}
+
+func (s *LobstersSource) normalize(id int64, list string, story *lobstersStory) JsonNewsItem {
+	item := JsonNewsItem{
+		Id:          id,
+		Title:       story.Title,
+		Url:         firstNonEmpty(story.Url, story.CommentsUrl),
+		Discussion:  story.CommentsUrl,
+		By:          lobstersSubmitter(story.Submitter),
+		Text:        story.Description,
+		Type:        "story",
+		Score:       story.Score,
+		Descendants: story.CommentCount,
+		Time:        time.Now().Unix(),
+		Source:      s.name,
+		Lists:       []string{list},
+		Tags:        story.Tags,
+	}
+
+	if created, err := time.Parse(time.RFC3339, story.CreatedAt); err == nil {
+		item.Time = created.Unix()
+	}
+
+	return item
+}
+
+// The submitter is a user name, or a user object in the older API versions
+func lobstersSubmitter(raw json.RawMessage) string {
+	var (
+		name string
+		user struct {
+			Username string `json:"username"`
+		}
+	)
+
+	if err := json.Unmarshal(raw, &name); err == nil {
+		return name
+	}
+
+	if err := json.Unmarshal(raw, &user); err == nil {
+		return user.Username
+	}
+
+	return ""
+}
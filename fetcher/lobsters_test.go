@@ -0,0 +1,90 @@
+package fetcher
+
+import (
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestLobstersSource(t *testing.T) {
+	const hottest = `[
+		{
+			"short_id": "abc123",
+			"short_id_url": "https://lobste.rs/s/abc123",
+			"created_at": "2022-10-15T08:08:59.000-05:00",
+			"title": "Some Title #1",
+			"url": "https://some-host/1-story-uri/",
+			"score": 25,
+			"comment_count": 4,
+			"description_plain": "",
+			"comments_url": "https://lobste.rs/s/abc123/some_title_1",
+			"submitter_user": "author",
+			"tags": ["linux", "networking"]
+		},
+		{
+			"short_id": "def456",
+			"created_at": "2022-10-15T09:00:00.000-05:00",
+			"title": "Ask: Some News",
+			"url": "",
+			"score": 3,
+			"comment_count": 1,
+			"comments_url": "https://lobste.rs/s/def456/ask_some_news",
+			"submitter_user": {"username": "author-2"},
+			"tags": ["ask"]
+		}
+	]`
+
+	sources, err := newSources(&Configuration{Sources: []SourceConfig{{Type: LobstersSourceType}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := sources[0]
+
+	httpmock.Activate()
+
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", LobstersBaseUrl+"/hottest.json", httpmock.NewStringResponder(200, hottest))
+
+	prefetched, err := source.Prefetch()
+	if err != nil {
+		t.Fatalf("Error while prefetching Lobsters stories, %v", err)
+	}
+
+	if len(*prefetched) != 2 {
+		t.Fatalf("Expected 2 stories, got %d", len(*prefetched))
+	}
+
+	item, _ := source.FetchOne((*prefetched)[0])
+
+	if item.Id != namespacedID(LobstersSourceType, "abc123") {
+		t.Errorf("Expected the ID to be namespaced")
+	}
+
+	if item.Title != "Some Title #1" || item.Url != "https://some-host/1-story-uri/" || item.By != "author" {
+		t.Errorf("Unexpected story %s - %s by %s", item.Title, item.Url, item.By)
+	}
+
+	if item.Score != 25 || item.Descendants != 4 || item.Time != 1665839339 {
+		t.Errorf("Unexpected score %d, comments %d or time %d", item.Score, item.Descendants, item.Time)
+	}
+
+	if len(item.Tags) != 2 || item.Tags[0] != "linux" || item.Source != LobstersSourceType {
+		t.Errorf("Unexpected tags %v or source %s", item.Tags, item.Source)
+	}
+
+	item, _ = source.FetchOne((*prefetched)[1])
+
+	if item.By != "author-2" || item.Url != "https://lobste.rs/s/def456/ask_some_news" {
+		t.Errorf("Unexpected text story %s by %s", item.Url, item.By)
+	}
+}
+
+func TestLobstersUnknownList(t *testing.T) {
+	settings := Configuration{Sources: []SourceConfig{{Type: LobstersSourceType, Lists: []string{"top"}}}}
+
+	if _, err := newSources(&settings); err == nil {
+		t.Error("Expected an unknown Lobsters list to fail")
+	}
+}
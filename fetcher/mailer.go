@@ -16,16 +16,19 @@ const EmailMimeHeaders = "Content-Type: multipart/alternative; boundary=\"bounda
 	"MIME-Version: 1.0" + DblCrLf
 const EmailSectionHeader = "--boundary-string" + CRLF + "Content-Type: %s; charset=\"utf-8\"" + CRLF +
 	"Content-Transfer-Encoding: base64" + CRLF + "MIME-Version: 1.0" + DblCrLf
-const DigestItemTextTemplate = "* %s - %s" + CRLF
-const DigestItemHTMLTemplate = "<li><a href=\"%s\">%s</a></li>" + CRLF
+const DigestItemTextTemplate = "* %s%s - %s (%s)%s%s" + CRLF
+const DigestItemHTMLTemplate = "<li>%s<a href=\"%s\">%s</a>%s <small>(%s)</small>%s</li>" + CRLF
+const DiscussionHTMLTemplate = " [<a href=\"%s\">discussion</a>]"
+const RelatedHTMLTemplate = " <small>also discussed at %s</small>"
+const RelatedLinkHTMLTemplate = "<a href=\"%s\">%s</a>"
+const DigestGroupTextTemplate = "%s:" + CRLF
+const DigestGroupHTMLTemplate = "<h3>%s</h3>" + CRLF
 const DigestHTMLTemplate = `<html>
 <head>HackerNews Digest</head>
 <body>
   <p>Hi!</p>
   <div>
-  <ul>
   %s
-  </ul>
   </div>
   <p>Generated: %s</p>
 </body>
@@ -54,7 +57,7 @@ func toBase64(input string) string {
 	return normalized
 }
 
-func (mailer *DigestMailer) prepareMessage(digest *[]DigestItem, emailTo, emailSubject string) string {
+func (mailer *DigestMailer) prepareMessage(digest *[]DigestGroup, emailTo, emailSubject string) string {
 	headers := map[string]string{
 		"From":    mailer.smtpConfig.From,
 		"Subject": emailSubject,
@@ -70,10 +73,41 @@ func (mailer *DigestMailer) prepareMessage(digest *[]DigestItem, emailTo, emailS
 
 	digestItemsTextBuilder.WriteString("Hi!" + DblCrLf)
 
-	for _, digestItem := range *digest {
-		digestItemsHTMLBuilder.WriteString(fmt.Sprintf(DigestItemHTMLTemplate,
-			digestItem.newsUrl, digestItem.newsTitle))
-		digestItemsTextBuilder.WriteString(fmt.Sprintf(DigestItemTextTemplate, digestItem.newsTitle, digestItem.newsUrl))
+	for _, group := range *digest {
+		if group.Title != "" {
+			digestItemsHTMLBuilder.WriteString(fmt.Sprintf(DigestGroupHTMLTemplate, group.Title))
+			digestItemsTextBuilder.WriteString(fmt.Sprintf(DigestGroupTextTemplate, group.Title))
+		}
+
+		digestItemsHTMLBuilder.WriteString("<ul>" + CRLF)
+
+		for _, digestItem := range group.Items {
+			discussionHTML := ""
+			if link := digestItem.discussionLink(); link != "" {
+				discussionHTML = fmt.Sprintf(DiscussionHTMLTemplate, link)
+			}
+
+			// The related items are linked by their hosts
+			relatedHTML := ""
+			if len(digestItem.related) > 0 {
+				links := make([]string, 0, len(digestItem.related))
+				for idx := range digestItem.related {
+					link := digestItem.related[idx].discussedAt()
+					links = append(links, fmt.Sprintf(RelatedLinkHTMLTemplate, link, urlHost(link)))
+				}
+
+				relatedHTML = fmt.Sprintf(RelatedHTMLTemplate, strings.Join(links, ", "))
+			}
+
+			digestItemsHTMLBuilder.WriteString(fmt.Sprintf(DigestItemHTMLTemplate, digestItem.listsLabel(),
+				digestItem.newsUrl, digestItem.newsTitle, discussionHTML, digestItem.statsLabel(), relatedHTML))
+			digestItemsTextBuilder.WriteString(fmt.Sprintf(DigestItemTextTemplate, digestItem.listsLabel(),
+				digestItem.newsTitle, digestItem.newsUrl, digestItem.statsLabel(), digestItem.discussionLabel(),
+				digestItem.relatedLabel()))
+		}
+
+		digestItemsHTMLBuilder.WriteString("</ul>" + CRLF)
+		digestItemsTextBuilder.WriteString(CRLF)
 	}
 
 	messageBuilder.WriteString(EmailMimeHeaders)
@@ -90,7 +124,7 @@ func (mailer *DigestMailer) prepareMessage(digest *[]DigestItem, emailTo, emailS
 }
 
 // Prepare and send an email with the list of the provided news items
-func (mailer *DigestMailer) SendEmail(digest *[]DigestItem, emailTo, emailSubject string) {
+func (mailer *DigestMailer) SendEmail(digest *[]DigestGroup, emailTo, emailSubject string) {
 	msg := mailer.prepareMessage(digest, emailTo, emailSubject)
 
 	if mailer.smtpConfig.Host == "" {
@@ -1,6 +1,8 @@
 package fetcher
 
 import (
+	"encoding/base64"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -20,7 +22,65 @@ func TestBodyToBase64(t *testing.T) {
 func TestSendMail(t *testing.T) {
 	mailer := DigestMailer{}
 	assert.NotPanics(t, func() {
-		mailer.SendEmail(&[]DigestItem{
-			{id: 1, newsTitle: "t", newsUrl: "url", createdAt: 12312}}, "", "")
+		mailer.SendEmail(&[]DigestGroup{{Items: []DigestItem{
+			{id: 1, newsTitle: "t", newsUrl: "url", createdAt: 12312}}}}, "", "")
 	}, "SendEmail should not panic with empty parameters")
 }
+
+// The text part is the first base64-encoded section of the message
+func decodeTextPart(t *testing.T, msg string) string {
+	sections := strings.Split(msg, "Content-Transfer-Encoding: base64"+CRLF+"MIME-Version: 1.0"+DblCrLf)
+	encoded := strings.ReplaceAll(strings.Split(sections[1], "--boundary-string")[0], CRLF, "")
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(decoded)
+}
+
+func TestPrepareMessageItemDetails(t *testing.T) {
+	mailer := DigestMailer{}
+	msg := mailer.prepareMessage(&[]DigestGroup{{Items: []DigestItem{
+		{id: 1, newsTitle: "Title", newsUrl: "http://localhost", score: 289, author: "pg", comments: 12,
+			discussionUrl: "https://news.ycombinator.com/item?id=1"}}}},
+		"to@example.com", "Subject")
+
+	decoded := decodeTextPart(t, msg)
+
+	if !strings.Contains(decoded, "* Title - http://localhost (289 points by pg, 12 comments)"+
+		" - discussion: https://news.ycombinator.com/item?id=1") {
+		t.Errorf("The item details are missing in the text part:\n%s", decoded)
+	}
+}
+
+func TestPrepareMessageGroups(t *testing.T) {
+	mailer := DigestMailer{}
+	msg := mailer.prepareMessage(&[]DigestGroup{
+		{Title: "Linux", Items: []DigestItem{{id: 1, newsTitle: "Kernel", newsUrl: "http://localhost/1"}}},
+		{Title: OtherGroupTitle, Items: []DigestItem{{id: 2, newsTitle: "Misc", newsUrl: "http://localhost/2"}}},
+	}, "to@example.com", "Subject")
+
+	decoded := decodeTextPart(t, msg)
+
+	linux, other := strings.Index(decoded, "Linux:"+CRLF+"* Kernel"), strings.Index(decoded, "Other:"+CRLF+"* Misc")
+	if linux < 0 || other < linux {
+		t.Errorf("Expected the items to be grouped under their headings:\n%s", decoded)
+	}
+}
+
+func TestPrepareMessageRelatedItems(t *testing.T) {
+	mailer := DigestMailer{}
+	msg := mailer.prepareMessage(&[]DigestGroup{{Items: []DigestItem{{id: 1, newsTitle: "Big news",
+		newsUrl: "http://localhost/1", related: []DigestItem{
+			{id: 2, newsUrl: "http://other/2", discussionUrl: "https://lobste.rs/s/2"},
+			{id: 3, newsUrl: "http://third/3"},
+		}}}}}, "to@example.com", "Subject")
+
+	decoded := decodeTextPart(t, msg)
+
+	if !strings.Contains(decoded, " - also discussed at: https://lobste.rs/s/2, http://third/3"+CRLF) {
+		t.Errorf("Expected the related items' links after the item:\n%s", decoded)
+	}
+}
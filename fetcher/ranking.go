@@ -0,0 +1,75 @@
+package fetcher
+
+import (
+	"math"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// Ranking defaults, used unless configured in "Digest"
+const (
+	// Points a matched filter of weight 1 is worth, next to the item's own points and comments
+	FilterMatchPoints     = 100
+	DefaultFilterWeight   = 1
+	DefaultScoreWeight    = 1
+	DefaultCommentsWeight = 1
+	// The age decay of the HackerNews ranking
+	DefaultGravity = 1.8
+)
+
+// Weight of a filter's match in the relevance score
+func filterWeight(filter *FilterItem) float64 {
+	if filter.Weight == 0 {
+		return DefaultFilterWeight
+	}
+
+	return filter.Weight
+}
+
+// Relevance score of a news item at the time: the summed weights of the filters it matched, its points
+// and its comments, decayed with its age like the HackerNews ranking, (points) / (hours + 2)^gravity
+func (f *Fetcher) relevance(newItem *JsonNewsItem, matched []FilterItem, now time.Time) float64 {
+	var weights float64
+
+	for idx := range matched {
+		weights += filterWeight(&matched[idx])
+	}
+
+	points := weights*FilterMatchPoints +
+		optional(f.Settings.Digest.ScoreWeight, DefaultScoreWeight)*float64(newItem.Score) +
+		optional(f.Settings.Digest.CommentsWeight, DefaultCommentsWeight)*float64(newItem.Descendants)
+
+	ageHours := max(now.Sub(time.Unix(newItem.Time, 0)).Hours(), 0)
+
+	return points / math.Pow(ageHours+2, optional(f.Settings.Digest.Gravity, DefaultGravity))
+}
+
+// Whether a news item's relevance is below the configured cutoff
+func (f *Fetcher) belowCutoff(relevance float64) bool {
+	return f.Settings.Digest.MinRelevance > 0 && relevance < f.Settings.Digest.MinRelevance
+}
+
+// Sort the digest by relevance, the most relevant items first, and cap it at the configured
+// number of items. Returns the kept items and the ones over the cap.
+func (f *Fetcher) rankDigest(digest []DigestItem) ([]DigestItem, []DigestItem) {
+	ranked := slices.Clone(digest)
+
+	slices.SortStableFunc(ranked, func(a, b DigestItem) int {
+		switch {
+		case a.relevance > b.relevance:
+			return -1
+		case a.relevance < b.relevance:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	maxItems := int(f.Settings.Digest.MaxItems)
+	if maxItems == 0 || len(ranked) <= maxItems {
+		return ranked, nil
+	}
+
+	return ranked[:maxItems], ranked[maxItems:]
+}
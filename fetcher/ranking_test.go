@@ -0,0 +1,109 @@
+package fetcher
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRelevance(t *testing.T) {
+	fetcher := Fetcher{}
+	at := time.Unix(1700000000, 0)
+	now := at.Unix()
+
+	heavy := []FilterItem{{Title: "Linux", Weight: 3}}
+	light := []FilterItem{{Title: "Css"}}
+
+	fresh := fetcher.relevance(&JsonNewsItem{Time: now}, heavy, at)
+	if expected := 300 / math.Pow(2, DefaultGravity); math.Abs(fresh-expected) > 0.01 {
+		t.Errorf("Expected a fresh item to score %f, got %f", expected, fresh)
+	}
+
+	if fetcher.relevance(&JsonNewsItem{Time: now}, light, at) >= fresh {
+		t.Errorf("Expected a heavier filter to rank higher")
+	}
+
+	if fetcher.relevance(&JsonNewsItem{Time: now - 10*3600}, heavy, at) >= fresh {
+		t.Errorf("Expected an older item to rank lower")
+	}
+
+	popular := fetcher.relevance(&JsonNewsItem{Time: now, Score: 200, Descendants: 150}, light, at)
+	if popular <= fresh {
+		t.Errorf("Expected points and comments to count, got %f and %f", popular, fresh)
+	}
+
+	fetcher.Settings.Digest = DigestConfig{ScoreWeight: ptr(0.5), CommentsWeight: ptr(0.1), Gravity: ptr(1.0)}
+
+	result := fetcher.relevance(&JsonNewsItem{Time: now, Score: 200, Descendants: 100}, nil, at)
+	if math.Abs(result-55) > 0.01 {
+		t.Errorf("Expected the configured weights to be used, got %f", result)
+	}
+
+	// The points and the age can be left out of the ranking
+	fetcher.Settings.Digest = DigestConfig{ScoreWeight: ptr(0.0), Gravity: ptr(0.0)}
+
+	result = fetcher.relevance(&JsonNewsItem{Time: now - 10*3600, Score: 200, Descendants: 100}, light, at)
+	if math.Abs(result-200) > 0.01 {
+		t.Errorf("Expected only the filter and the comments to count, got %f", result)
+	}
+}
+
+func TestRankDigest(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{Digest: DigestConfig{MaxItems: 2}}}
+	digest := []DigestItem{{id: 1, relevance: 5}, {id: 2, relevance: 50}, {id: 3, relevance: 10}}
+
+	kept, dropped := fetcher.rankDigest(digest)
+
+	if len(kept) != 2 || kept[0].id != 2 || kept[1].id != 3 {
+		t.Errorf("Expected the 2 most relevant items, got %v", kept)
+	}
+
+	if len(dropped) != 1 || dropped[0].id != 1 {
+		t.Errorf("Expected the least relevant item to be dropped, got %v", dropped)
+	}
+
+	fetcher.Settings.Digest.MaxItems = 0
+
+	if kept, dropped := fetcher.rankDigest(digest); len(kept) != 3 || len(dropped) != 0 {
+		t.Errorf("Expected no cap by default, got %v and %v", kept, dropped)
+	}
+}
+
+func TestRelevanceCutoff(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		Filters:  []FilterItem{{Title: "Important", Value: "important", Weight: 10}, {Title: "Other", Value: "other"}},
+		Digest:   DigestConfig{MinRelevance: 100},
+		Database: Database{Driver: "sqlite3", Database: ":memory:"},
+	}}
+
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
+
+	if err := fetcher.setUpRepository(); err != nil {
+		t.Fatalf("Error while initializing the repository, %v", err)
+	}
+
+	defer fetcher.repository.Close()
+
+	now := time.Now().Unix()
+	source := &staticSource{items: []JsonNewsItem{
+		{Id: 1, Title: "Other news", Url: "http://host/1", Time: now},
+		{Id: 2, Title: "Important news", Url: "http://host/2", Time: now},
+	}}
+
+	prefetched, _ := source.Prefetch()
+
+	unfiltered, filtered, err := fetcher.filter(source, prefetched)
+	if err != nil {
+		t.Fatalf("Error while filtering news items, %v", err)
+	}
+
+	if len(*filtered) != 1 || (*filtered)[0].id != 2 {
+		t.Errorf("Expected only the relevant item in the digest, got %v", *filtered)
+	}
+
+	if len(*unfiltered) != 2 || (*unfiltered)[0].status != "" {
+		t.Errorf("Expected the item below the cutoff to be seen, got %v", *unfiltered)
+	}
+}
@@ -0,0 +1,130 @@
+package fetcher
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	RedditSourceType = "reddit"
+	RedditBaseUrl    = "https://www.reddit.com"
+)
+
+type redditListing struct {
+	Data struct {
+		Children []struct {
+			Data redditPost `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+type redditPost struct {
+	Name        string  `json:"name"`
+	Title       string  `json:"title"`
+	Url         string  `json:"url"`
+	Permalink   string  `json:"permalink"`
+	Author      string  `json:"author"`
+	SelfText    string  `json:"selftext"`
+	Flair       string  `json:"link_flair_text"`
+	CreatedUtc  float64 `json:"created_utc"`
+	Score       int64   `json:"score"`
+	NumComments int64   `json:"num_comments"`
+	IsSelf      bool    `json:"is_self"`
+}
+
+// RedditSource Top posts of the day of the configured subreddits, with their flair
+type RedditSource struct {
+	name       string
+	baseUrl    string
+	subreddits []string
+	items      map[int64]JsonNewsItem
+	client     *HttpClient
+}
+
+func newRedditSource(_ *Configuration, config *SourceConfig, client *HttpClient) (Source, error) {
+	if len(config.Subreddits) == 0 {
+		return nil, errors.New("no subreddits are set")
+	}
+
+	source := &RedditSource{name: config.Name, baseUrl: config.Url, subreddits: config.Subreddits, client: client}
+
+	if source.baseUrl == "" {
+		source.baseUrl = RedditBaseUrl
+	}
+
+	return source, nil
+}
+
+func (s *RedditSource) Name() string {
+	return s.name
+}
+
+// Prefetch Get the top posts of the subreddits merged and deduplicated
+func (s *RedditSource) Prefetch() (*[]int64, error) {
+	var result []int64
+
+	s.items = make(map[int64]JsonNewsItem)
+
+	for _, subreddit := range s.subreddits {
+		var listing redditListing
+
+		if err := s.client.GetJSON(fmt.Sprintf("%s/r/%s/top.json?t=day", s.baseUrl, subreddit), &listing); err != nil {
+			return &result, err
+		}
+
+		for _, child := range listing.Data.Children {
+			id := namespacedID(RedditSourceType, child.Data.Name)
+
+			if item, seen := s.items[id]; seen {
+				item.Lists = append(item.Lists, "r/"+subreddit)
+				s.items[id] = item
+
+				continue
+			}
+
+			s.items[id] = s.normalize(id, subreddit, &child.Data)
+			result = append(result, id)
+		}
+	}
+
+	return &result, nil
+}
+
+// FetchOne Return a post of the last prefetched listings
+func (s *RedditSource) FetchOne(id int64) (JsonNewsItem, error) {
+	item, ok := s.items[id]
+	if !ok {
+		return JsonNewsItem{}, fmt.Errorf("item %d of the subreddits' top: %w", id, ErrNotListed)
+	}
+
+	return item, nil
+}
+
+func (s *RedditSource) normalize(id int64, subreddit string, post *redditPost) JsonNewsItem {
+	item := JsonNewsItem{
+		Id:          id,
+		Title:       post.Title,
+		Url:         post.Url,
+		By:          post.Author,
+		Text:        post.SelfText,
+		Type:        "story",
+		Score:       post.Score,
+		Descendants: post.NumComments,
+		Time:        int64(post.CreatedUtc),
+		Source:      s.name,
+		Lists:       []string{"r/" + subreddit},
+		Discussion:  strings.TrimSuffix(s.baseUrl, "/") + post.Permalink,
+	}
+
+	// Self posts link to their own discussion
+	if post.IsSelf || item.Url == "" {
+		item.Url = item.Discussion
+	}
+
+	if post.Flair != "" {
+		item.Tags = []string{post.Flair}
+	}
+
+	return item
+}
@@ -0,0 +1,100 @@
+package fetcher
+
+import (
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestRedditSource(t *testing.T) {
+	const (
+		golang = `{"kind": "Listing", "data": {"children": [
+			{"kind": "t3", "data": {
+				"name": "t3_abc123",
+				"title": "Some Title #1",
+				"url": "https://some-host/1-story-uri/",
+				"permalink": "/r/golang/comments/abc123/some_title_1/",
+				"author": "author",
+				"selftext": "",
+				"link_flair_text": "show & tell",
+				"created_utc": 1665839339.0,
+				"score": 120,
+				"num_comments": 30,
+				"is_self": false
+			}},
+			{"kind": "t3", "data": {
+				"name": "t3_def456",
+				"title": "Some News",
+				"url": "https://www.reddit.com/r/golang/comments/def456/some_news/",
+				"permalink": "/r/golang/comments/def456/some_news/",
+				"author": "author-2",
+				"selftext": "Some question",
+				"link_flair_text": null,
+				"created_utc": 1665839340.0,
+				"score": 5,
+				"num_comments": 2,
+				"is_self": true
+			}}
+		]}}`
+		rust = `{"kind": "Listing", "data": {"children": [
+			{"kind": "t3", "data": {"name": "t3_abc123", "title": "Some Title #1", "created_utc": 1665839339.0}}
+		]}}`
+	)
+
+	sources, err := newSources(&Configuration{Sources: []SourceConfig{
+		{Type: RedditSourceType, Subreddits: []string{"golang", "rust"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := sources[0]
+
+	httpmock.Activate()
+
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", RedditBaseUrl+"/r/golang/top.json?t=day",
+		httpmock.NewStringResponder(200, golang))
+	httpmock.RegisterResponder("GET", RedditBaseUrl+"/r/rust/top.json?t=day", httpmock.NewStringResponder(200, rust))
+
+	prefetched, err := source.Prefetch()
+	if err != nil {
+		t.Fatalf("Error while prefetching Reddit posts, %v", err)
+	}
+
+	if len(*prefetched) != 2 {
+		t.Fatalf("Expected 2 posts, got %d", len(*prefetched))
+	}
+
+	item, _ := source.FetchOne((*prefetched)[0])
+
+	if item.Id != namespacedID(RedditSourceType, "t3_abc123") || item.Source != RedditSourceType {
+		t.Errorf("Expected the ID and the source to be namespaced")
+	}
+
+	if item.Score != 120 || item.Descendants != 30 || item.By != "author" || item.Time != 1665839339 {
+		t.Errorf("Unexpected score %d, comments %d, author %s or time %d",
+			item.Score, item.Descendants, item.By, item.Time)
+	}
+
+	if len(item.Tags) != 1 || item.Tags[0] != "show & tell" {
+		t.Errorf("Expected the flair to be a tag, got %v", item.Tags)
+	}
+
+	if len(item.Lists) != 2 || item.Lists[0] != "r/golang" || item.Lists[1] != "r/rust" {
+		t.Errorf("Expected the post to come from both subreddits, got %v", item.Lists)
+	}
+
+	item, _ = source.FetchOne((*prefetched)[1])
+
+	if item.Url != RedditBaseUrl+"/r/golang/comments/def456/some_news/" || item.Text != "Some question" {
+		t.Errorf("Unexpected self post %s: %s", item.Url, item.Text)
+	}
+}
+
+func TestRedditWithoutSubreddits(t *testing.T) {
+	if _, err := newSources(&Configuration{Sources: []SourceConfig{{Type: RedditSourceType}}}); err == nil {
+		t.Error("Expected a Reddit source without subreddits to fail")
+	}
+}
@@ -0,0 +1,114 @@
+package fetcher
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Period of the stored items re-filtered, unless set with --since
+const DefaultRefilterSince = "7d"
+
+// ParseSince Parse a period like "7d", "12h" or "90m"; days are not among the Go durations
+func ParseSince(since string) (time.Duration, error) {
+	if days, found := strings.CutSuffix(since, "d"); found {
+		count, err := strconv.ParseUint(days, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid period %q", since)
+		}
+
+		return time.Duration(count) * 24 * time.Hour, nil
+	}
+
+	period, err := time.ParseDuration(since)
+	if err != nil || period < 0 {
+		return 0, fmt.Errorf("invalid period %q", since)
+	}
+
+	return period, nil
+}
+
+// A stored news item as if it had just been fetched, to run it through the filters again. Its
+// points and comments are the last ones seen, so the age limits do not apply to it, and neither
+// does the age decay of its relevance.
+func fetchedNow(storedItem JsonNewsItem) JsonNewsItem {
+	storedItem.Time = time.Now().Unix()
+
+	return storedItem
+}
+
+// Digest items of the stored news items that would get through the filters if they were fetched
+// now. The reposts, and the stories delivered lately under other titles, are left out.
+func (f *Fetcher) refilter(items []JsonNewsItem) ([]DigestItem, error) {
+	var digest []DigestItem
+
+	for idx := range items {
+		newItem := fetchedNow(items[idx])
+
+		if f.evaluate(&newItem) != verdictInclude {
+			continue
+		}
+
+		matched := f.matchingFilters(&newItem)
+
+		digestItem := newDigestItem(&items[idx])
+		digestItem.filters = filterTitles(matched)
+		digestItem.relevance = f.relevance(&newItem, matched, time.Now())
+
+		if f.belowCutoff(digestItem.relevance) {
+			continue
+		}
+
+		repost, err := f.isRepost(&digestItem)
+		if err != nil {
+			return nil, err
+		}
+
+		if !repost && !f.similarToDelivered(digestItem.newsTitle) {
+			f.addDigestLink(&digestItem)
+			digest = append(digest, digestItem)
+		}
+	}
+
+	return digest, nil
+}
+
+// Refilter Run the stored news items of the period through the current filters, and deliver
+// the matches that have never been delivered, e.g. the older matches of a newly added filter
+func (f *Fetcher) Refilter(since time.Duration) (*Results, error) {
+	if err := f.prepareFilters(); err != nil {
+		return nil, err
+	}
+
+	if err := f.setUpRepository(); err != nil {
+		return nil, err
+	}
+
+	defer f.repository.Close()
+
+	if err := f.loadClassifier(&f.repository); err != nil {
+		return nil, err
+	}
+
+	if err := f.loadDeliveredTitles(); err != nil {
+		return nil, err
+	}
+
+	items, err := f.repository.UndeliveredItems(time.Now().Add(-since))
+	if err != nil {
+		return nil, err
+	}
+
+	digest, err := f.refilter(items)
+	if err != nil {
+		return nil, err
+	}
+
+	digest, err = f.deliver(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Results{Delivered: len(digest), Filters: len(f.filters)}, nil
+}
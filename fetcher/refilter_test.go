@@ -0,0 +1,80 @@
+package fetcher
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	for since, expected := range map[string]time.Duration{
+		"7d":  7 * 24 * time.Hour,
+		"12h": 12 * time.Hour,
+		"90m": 90 * time.Minute,
+	} {
+		if period, err := ParseSince(since); err != nil || period != expected {
+			t.Errorf("Expected %s to be %v, got %v, %v", since, expected, period, err)
+		}
+	}
+
+	for _, since := range []string{"", "d", "-1d", "week", "-5h"} {
+		if _, err := ParseSince(since); err == nil {
+			t.Errorf("Expected %q to be an invalid period", since)
+		}
+	}
+}
+
+func TestRefilter(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		Filters:     []FilterItem{{Title: "WebAssembly", Value: "webassembly,wasm"}},
+		MinScore:    10,
+		MaxAgeHours: 1,
+		Database:    Database{Driver: "sqlite3", Database: ":memory:"},
+	}}
+
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
+
+	if err := fetcher.setUpRepository(); err != nil {
+		t.Fatalf("Error while initializing the repository, %v", err)
+	}
+
+	defer fetcher.repository.Close()
+
+	// Older than the age limit, which does not apply to the re-filtered items
+	createdAt := time.Now().Add(-72 * time.Hour).Unix()
+
+	err := fetcher.repository.UpdateItems(&[]DigestItem{
+		{id: 1, newsTitle: "WebAssembly in 2026", newsUrl: "http://host/1", createdAt: createdAt, score: 50},
+		{id: 2, newsTitle: "Wasm is everywhere", newsUrl: "http://host/2", createdAt: createdAt, score: 50,
+			status: StatusDelivered},
+		{id: 3, newsTitle: "Wasm without points", newsUrl: "http://host/3", createdAt: createdAt, score: 1},
+		{id: 4, newsTitle: "Unrelated news", newsUrl: "http://host/4", createdAt: createdAt, score: 50},
+	})
+	if err != nil {
+		t.Fatalf("Could not store the items, %v", err)
+	}
+
+	items, err := fetcher.repository.UndeliveredItems(time.Now().Add(-7 * 24 * time.Hour))
+	if err != nil {
+		t.Fatalf("Could not load the undelivered items, %v", err)
+	}
+
+	digest, err := fetcher.refilter(items)
+	if err != nil {
+		t.Fatalf("Could not re-filter the items, %v", err)
+	}
+
+	if len(digest) != 1 || digest[0].id != 1 || digest[0].createdAt != createdAt ||
+		digest[0].filters[0] != "WebAssembly" {
+		t.Fatalf("Expected only the new match meeting the thresholds, got %v", digest)
+	}
+
+	if _, err := fetcher.deliver(digest); err != nil {
+		t.Fatalf("Could not deliver the digest, %v", err)
+	}
+
+	if items, _ := fetcher.repository.UndeliveredItems(time.Now().Add(-7 * 24 * time.Hour)); len(items) != 2 {
+		t.Errorf("Expected the delivered match not to be re-filtered again, got %v", items)
+	}
+}
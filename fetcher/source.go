@@ -0,0 +1,149 @@
+package fetcher
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"golang.org/x/exp/slices"
+)
+
+// Bit set in the IDs made up for the items of non-HackerNews sources. HackerNews item IDs
+// are far below it, so the made up IDs never collide with them in the repository.
+const namespacedIDBit = int64(1) << 62
+
+// Source A feed of news items the digest is built from. Sources list the IDs of their
+// current items first, so that the items already in the repository are not fetched again.
+type Source interface {
+	// Name of the source, stored with every item it yields
+	Name() string
+	// IDs of the items the source lists now, in the source's ranking order
+	Prefetch() (*[]int64, error)
+	// One news item, normalized to the HackerNews item layout
+	FetchOne(id int64) (JsonNewsItem, error)
+}
+
+// Creates a source from its configuration; all the sources share one HTTP client
+type sourceFactory func(settings *Configuration, config *SourceConfig, client *HttpClient) (Source, error)
+
+// Source types whose items have no points and comments, so the score and comment limits do not
+// apply to them
+var UnscoredSourceTypes = []string{FeedSourceType}
+
+// Source types available in the configuration
+var sourceRegistry = map[string]sourceFactory{
+	HackerNewsSourceType: newHackerNewsSource,
+	FeedSourceType:       newFeedSource,
+	LobstersSourceType:   newLobstersSource,
+	RedditSourceType:     newRedditSource,
+	AlgoliaSourceType:    newAlgoliaSource,
+}
+
+// Stable repository ID for an item of a source without integer IDs, hashed from the namespace
+// (the source type, or the URL of a feed) and the item's own key, e.g. its GUID or link
+func namespacedID(namespace, key string) int64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(namespace + ":" + key))
+
+	return int64(hash.Sum64()&uint64(namespacedIDBit-1)) | namespacedIDBit
+}
+
+// Configured sources, named after their types unless named otherwise. With no sources
+// configured, the HackerNews API at ApiBaseUrl with the Fetch.Lists story lists is used.
+func sourceConfigs(settings *Configuration) []SourceConfig {
+	if len(settings.Sources) == 0 {
+		return []SourceConfig{{Type: HackerNewsSourceType, Name: HackerNewsSourceType}}
+	}
+
+	configs := make([]SourceConfig, 0, len(settings.Sources))
+
+	for _, config := range settings.Sources {
+		if config.Name == "" {
+			config.Name = config.Type
+		}
+
+		configs = append(configs, config)
+	}
+
+	return configs
+}
+
+// Space of the IDs of a source's items. The sources reading HackerNews share its item IDs, and
+// the Lobsters and the Reddit sources share the IDs made up from the stories' own IDs, while
+// every feed has IDs of its own.
+func idSpace(config *SourceConfig) string {
+	switch config.Type {
+	case AlgoliaSourceType:
+		return HackerNewsSourceType
+	case FeedSourceType:
+		return FeedSourceType + ":" + config.Url
+	default:
+		return config.Type
+	}
+}
+
+// Names of the other configured sources whose items share the IDs of the named source's items,
+// so that an item stored from one of them is not fetched again from another. The items stored
+// before the sources were configurable are HackerNews items of the "hackernews" source.
+func sharingSources(settings *Configuration, name string) []string {
+	var sharing []string
+
+	configs := sourceConfigs(settings)
+
+	idx := slices.IndexFunc(configs, func(config SourceConfig) bool { return config.Name == name })
+	if idx < 0 {
+		return nil
+	}
+
+	space := idSpace(&configs[idx])
+
+	for _, config := range configs {
+		if config.Name != name && idSpace(&config) == space {
+			sharing = append(sharing, config.Name)
+		}
+	}
+
+	if space == HackerNewsSourceType && name != HackerNewsSourceType &&
+		!slices.Contains(sharing, HackerNewsSourceType) {
+		sharing = append(sharing, HackerNewsSourceType)
+	}
+
+	return sharing
+}
+
+// Create the configured sources. With no sources configured, the HackerNews API
+// at ApiBaseUrl with the Fetch.Lists story lists is used.
+func newSources(settings *Configuration) ([]Source, error) {
+	configs := sourceConfigs(settings)
+
+	client, err := newHttpClient(&settings.Http)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]Source, 0, len(configs))
+	names := make(map[string]bool)
+
+	for idx := range configs {
+		config := configs[idx]
+
+		factory, ok := sourceRegistry[config.Type]
+		if !ok {
+			return nil, fmt.Errorf("source #%d has an unknown type %q", idx+1, config.Type)
+		}
+
+		if names[config.Name] {
+			return nil, fmt.Errorf("source #%d: the name %q is used more than once", idx+1, config.Name)
+		}
+
+		names[config.Name] = true
+
+		source, err := factory(settings, &config, client)
+		if err != nil {
+			return nil, fmt.Errorf("source %q: %w", config.Name, err)
+		}
+
+		sources = append(sources, source)
+	}
+
+	return sources, nil
+}
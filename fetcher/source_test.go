@@ -0,0 +1,130 @@
+package fetcher
+
+import (
+	"fmt"
+	"testing"
+)
+
+// A source serving the items from memory
+type staticSource struct {
+	items []JsonNewsItem
+}
+
+func (s *staticSource) Name() string {
+	return "static"
+}
+
+func (s *staticSource) Prefetch() (*[]int64, error) {
+	ids := make([]int64, 0, len(s.items))
+
+	for _, item := range s.items {
+		ids = append(ids, item.Id)
+	}
+
+	return &ids, nil
+}
+
+func (s *staticSource) FetchOne(id int64) (JsonNewsItem, error) {
+	for _, item := range s.items {
+		if item.Id == id {
+			item.Source = s.Name()
+			return item, nil
+		}
+	}
+
+	return JsonNewsItem{}, fmt.Errorf("no item %d", id)
+}
+
+func TestNewSourcesDefault(t *testing.T) {
+	sources, err := newSources(&Configuration{ApiBaseUrl: "http://localhost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sources) != 1 || sources[0].Name() != HackerNewsSourceType {
+		t.Fatalf("Expected the HackerNews source by default, got %v", sources)
+	}
+
+	hackerNews, ok := sources[0].(*HackerNewsSource)
+	if !ok || hackerNews.baseUrl != "http://localhost" || hackerNews.lists[0] != DefaultStoryList {
+		t.Errorf("The default source should use ApiBaseUrl and the top stories")
+	}
+}
+
+func TestNewSourcesErrors(t *testing.T) {
+	testCases := map[string][]SourceConfig{
+		"unknown type":   {{Type: "gopher"}},
+		"duplicate name": {{Type: HackerNewsSourceType}, {Type: HackerNewsSourceType}},
+	}
+
+	for name, configs := range testCases {
+		if _, err := newSources(&Configuration{Sources: configs}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSharingSources(t *testing.T) {
+	settings := &Configuration{Sources: []SourceConfig{
+		{Type: HackerNewsSourceType, Name: "hn"},
+		{Type: AlgoliaSourceType},
+		{Type: FeedSourceType, Name: "blog", Url: "http://some-host/blog.xml"},
+		{Type: FeedSourceType, Name: "news", Url: "http://some-host/news.xml"},
+		{Type: FeedSourceType, Name: "news-again", Url: "http://some-host/news.xml"},
+		{Type: LobstersSourceType, Name: "lobsters-hot"},
+		{Type: LobstersSourceType, Name: "lobsters-new"},
+	}}
+
+	testCases := map[string][]string{
+		// The items stored before the sources were configurable are also HackerNews items
+		"hn":           {AlgoliaSourceType, HackerNewsSourceType},
+		"algolia":      {"hn", HackerNewsSourceType},
+		"blog":         nil,
+		"news":         {"news-again"},
+		"lobsters-hot": {"lobsters-new"},
+		"unknown":      nil,
+	}
+
+	for name, expected := range testCases {
+		if sharing := sharingSources(settings, name); fmt.Sprint(sharing) != fmt.Sprint(expected) {
+			t.Errorf("%s: expected the sharing sources %v, got %v", name, expected, sharing)
+		}
+	}
+}
+
+func TestFilterOtherSource(t *testing.T) {
+	fetcher := Fetcher{Settings: Configuration{
+		Filters:  []FilterItem{{Title: "Test filter", Value: "title"}},
+		Database: Database{Driver: "sqlite3", Database: ":memory:"},
+	}}
+
+	if err := fetcher.prepareFilters(); err != nil {
+		t.Fatalf("Could not prepare the filters, %v", err)
+	}
+
+	if err := fetcher.setUpRepository(); err != nil {
+		t.Fatalf("Error while initializing the repository, %v", err)
+	}
+
+	defer fetcher.repository.Close()
+
+	source := &staticSource{items: []JsonNewsItem{
+		{Id: 1, Title: "Some Title", Url: "http://host/1"},
+		{Id: 2, Title: "Some News", Url: "http://host/2"},
+	}}
+
+	prefetched, _ := source.Prefetch()
+
+	unfiltered, filtered, err := fetcher.filter(source, prefetched)
+	if err != nil {
+		t.Fatalf("Error while filtering news items, %v", err)
+	}
+
+	if len(*unfiltered) != 2 || len(*filtered) != 1 {
+		t.Fatalf("Expected 2 stored and 1 digest items, got %d and %d", len(*unfiltered), len(*filtered))
+	}
+
+	if (*filtered)[0].source != "static" {
+		t.Errorf("Expected the item to keep its source, got %s", (*filtered)[0].source)
+	}
+}
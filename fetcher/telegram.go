@@ -5,6 +5,7 @@ import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"log"
 	"strconv"
+	"strings"
 )
 
 // DigestTelegram Telegram data type and its methods
@@ -13,18 +14,25 @@ type DigestTelegram struct {
 }
 
 // Prepare the message to be sent to Telegram
-func (telegram *DigestTelegram) prepareMessage(digest *[]DigestItem) string {
+func (telegram *DigestTelegram) prepareMessage(digest *[]DigestGroup) string {
 	message := ""
 
-	for _, item := range *digest {
-		message += item.newsTitle + " - " + item.newsUrl + "\n"
+	for _, group := range *digest {
+		if group.Title != "" {
+			message += group.Title + ":\n"
+		}
+
+		for _, item := range group.Items {
+			message += item.listsLabel() + item.newsTitle + " - " + item.newsUrl + " (" + item.statsLabel() + ")" +
+				item.discussionLabel() + item.relatedLabel() + "\n"
+		}
 	}
 
 	return message
 }
 
 // SendTelegram Prepare and send an Telegram message from the list of the provided news items
-func (telegram *DigestTelegram) SendTelegram(digest *[]DigestItem, tgConfig TelegramConfig) {
+func (telegram *DigestTelegram) SendTelegram(digest *[]DigestGroup, tgConfig TelegramConfig) {
 	bot, err := tgbotapi.NewBotAPI(tgConfig.Token)
 	if err != nil {
 		log.Panic(err)
@@ -38,15 +46,44 @@ func (telegram *DigestTelegram) SendTelegram(digest *[]DigestItem, tgConfig Tele
 		log.Panic(err)
 	}
 
-	for _, item := range *digest {
-		message := fmt.Sprintf("*%s*\n\n[%s](%s)", item.newsTitle, item.newsUrl, item.newsUrl)
+	send := func(message string) {
 		msg := tgbotapi.NewMessage(int64(chatID), message)
 		msg.ParseMode = "Markdown"
-		_, err = bot.Send(msg)
-		if err != nil {
+		if _, err := bot.Send(msg); err != nil {
 			log.Panic(err)
 		}
 	}
 
+	for _, group := range *digest {
+		// Every group starts with a heading message
+		if group.Title != "" {
+			send(fmt.Sprintf("*%s*", tgbotapi.EscapeText(tgbotapi.ModeMarkdown, group.Title)))
+		}
+
+		for _, item := range group.Items {
+			message := fmt.Sprintf("*%s*\n%s\n\n[%s](%s)", item.newsTitle,
+				tgbotapi.EscapeText(tgbotapi.ModeMarkdown, item.statsLabel()), item.newsUrl, item.newsUrl)
+			if link := item.discussionLink(); link != "" {
+				message += fmt.Sprintf(" | [discussion](%s)", link)
+			}
+
+			if len(item.related) > 0 {
+				links := make([]string, 0, len(item.related))
+				for idx := range item.related {
+					link := item.related[idx].discussedAt()
+					links = append(links, fmt.Sprintf("[%s](%s)", urlHost(link), link))
+				}
+
+				message += "\nAlso discussed at " + strings.Join(links, ", ")
+			}
+
+			if len(item.lists) > 0 {
+				message += fmt.Sprintf("\n_%s_", item.listNames())
+			}
+
+			send(message)
+		}
+	}
+
 	log.Printf("Message sent to chat ID %d", chatID)
 }
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	newsFetcher "github.com/utking/hackernews_digest_go/fetcher"
 )
@@ -44,9 +45,79 @@ func main() {
 		return
 	}
 
+	if len(args.TestFilter) > 0 || args.TestFile != "" {
+		inputs := args.TestFilter
+
+		if args.TestFile != "" {
+			fileInputs, err := newsFetcher.ReadTestInputs(args.TestFile)
+			if err != nil {
+				log.Fatalln(err)
+			}
+
+			inputs = append(inputs, fileInputs...)
+		}
+
+		if err = fetcher.TestFilters(inputs, os.Stdout); err != nil {
+			log.Fatalln(err)
+		}
+
+		return
+	}
+
+	if len(args.Relevant) > 0 || len(args.Irrelevant) > 0 {
+		trained, err := fetcher.Train(args.Relevant, args.Irrelevant)
+		fmt.Printf("Trained items: %d\n", trained)
+
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		return
+	}
+
+	if args.Backtest != "" {
+		candidatePath := args.Backtest
+
+		if candidatePath[0] != '/' {
+			if cwd, err = os.Getwd(); err != nil {
+				log.Fatalln("Cannot find what directory we are in")
+			}
+
+			candidatePath = filepath.Join(cwd, candidatePath)
+		}
+
+		candidate, err := newsFetcher.GetConfig(candidatePath)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if err = fetcher.Backtest(candidate, os.Stdout); err != nil {
+			log.Fatalln(err)
+		}
+
+		return
+	}
+
+	if args.Refilter {
+		var since time.Duration
+
+		if since, err = newsFetcher.ParseSince(args.Since); err != nil {
+			log.Fatalln(err)
+		}
+
+		if results, err = fetcher.Refilter(since); err != nil {
+			log.Fatalln(err)
+		}
+
+		fmt.Printf("Filters: %d\nDelivered stored items: %d\n", results.Filters, results.Delivered)
+
+		return
+	}
+
 	if results, err = fetcher.Run(); err != nil {
 		log.Fatalln(err)
 	}
 
-	fmt.Printf("Filters: %d\nFetched new items: %d\n", results.Filters, results.NewItems)
+	fmt.Printf("Filters: %d\nFetched new items: %d\nDeferred items: %d\nFailed items: %d\n", results.Filters,
+		results.NewItems, results.Deferred, results.Failed)
 }